@@ -0,0 +1,228 @@
+package goqux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrBatchNotSent is returned by BatchResult.Result before its batch is sent.
+var ErrBatchNotSent = errors.New("goqux: batch is not sent")
+
+// Batcher sends a batch of queries, it is implemented by *pgx.Conn, *pgxpool.Pool and pgx.Tx.
+type Batcher interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// Batch queues goqux queries with the Queue functions and sends them all in a single round trip with Send.
+// Each Queue function returns a BatchResult holding the typed result of its query once the batch is sent.
+// Postgres runs the queries of a batch in an implicit transaction, unless the batch is sent inside one, so a failing
+// query fails the queries after it and rolls back the ones before it.
+type Batch struct {
+	queued []batchQuery
+	// err is the first error building a queued query, the batch isn't sent if it's set
+	err  error
+	sent bool
+}
+
+// batchQuery is a queued query, read scans its rows into the BatchResult and done sets the BatchResult error.
+type batchQuery struct {
+	op    string
+	table string
+	query string
+	args  []any
+	read  func(rows pgx.Rows) error
+	done  func(err error)
+	// redacted rebuilds the query with its sensitive values redacted for a QueryError
+	redacted redactedQuery
+}
+
+// NewBatch returns an empty batch.
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Len returns the number of queued queries.
+func (b *Batch) Len() int {
+	return len(b.queued)
+}
+
+// Send sends the queued queries in a single round trip and sets the result of each BatchResult. It returns the first
+// error building or running a query, a batch with a query that failed to build isn't sent. A batch can only be sent once.
+func (b *Batch) Send(ctx context.Context, db Batcher) error {
+	if b.sent {
+		return fmt.Errorf("goqux: batch is already sent")
+	}
+	b.sent = true
+	if b.err != nil {
+		for _, q := range b.queued {
+			q.done(fmt.Errorf("%w: %w", ErrBatchNotSent, b.err))
+		}
+		return b.err
+	}
+	if len(b.queued) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, q := range b.queued {
+		batch.Queue(q.query, q.args...)
+	}
+	results := db.SendBatch(ctx, batch)
+	var firstErr error
+	for _, q := range b.queued {
+		err := readBatchQuery(results, q)
+		if err != nil {
+			err = newQueryError(q.op, q.table, q.query, q.args, q.redacted, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		q.done(err)
+	}
+	if err := results.Close(); err != nil && firstErr == nil {
+		return fmt.Errorf("goqux: failed to close batch: %w", translateError(err))
+	}
+	return firstErr
+}
+
+func readBatchQuery(results pgx.BatchResults, q batchQuery) error {
+	rows, err := results.Query()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if err := q.read(rows); err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+// BatchResult is the result of a query queued in a Batch.
+type BatchResult[T any] struct {
+	value T
+	err   error
+	done  bool
+}
+
+// Result returns the result of the query, or ErrBatchNotSent before the batch is sent.
+func (r *BatchResult[T]) Result() (T, error) {
+	if !r.done {
+		var zero T
+		return zero, ErrBatchNotSent
+	}
+	return r.value, r.err
+}
+
+// queue queues the built query, or records its build error.
+func queue[T any](b *Batch, op, table, query string, args []any, buildErr error, redacted redactedQuery, read func(rows pgx.Rows) (T, error)) *BatchResult[T] {
+	r := &BatchResult[T]{}
+	if buildErr != nil {
+		r.done, r.err = true, buildErr
+		if b.err == nil {
+			b.err = buildErr
+		}
+		return r
+	}
+	b.queued = append(b.queued, batchQuery{
+		op:       op,
+		table:    table,
+		query:    query,
+		args:     args,
+		redacted: redacted,
+		read: func(rows pgx.Rows) error {
+			var err error
+			r.value, err = read(rows)
+			return err
+		},
+		done: func(err error) {
+			r.done, r.err = true, err
+			if err != nil {
+				var zero T
+				r.value = zero
+			}
+		},
+	})
+	return r
+}
+
+func scanBatchAll[T any](rows pgx.Rows) ([]T, error) {
+	results := make([]T, 0)
+	err := pgxscan.ScanAll(&results, rows)
+	return results, err
+}
+
+func scanBatchOne[T any](rows pgx.Rows) (T, error) {
+	var result T
+	err := pgxscan.ScanOne(&result, rows)
+	return result, err
+}
+
+// QueueQuery queues a query built with a Build function or a goqu dataset, its rows are scanned into a slice of T.
+// Its arguments are all redacted from a QueryError, unless the redaction is RedactNone.
+func QueueQuery[T any](b *Batch, query string, args ...any) *BatchResult[[]T] {
+	return queue(b, "query", "", query, args, nil, nil, scanBatchAll[T])
+}
+
+// QueueSelect queues the query of Select.
+func QueueSelect[T any](b *Batch, tableName string, options ...SelectOption) *BatchResult[[]T] {
+	sd := buildSelectDataset(tableName, new(T), options...)
+	query, args, err := sd.ToSQL()
+	return queue(b, "select", tableName, query, args, err, redactedSelect(sd, reflect.TypeFor[T]()), scanBatchAll[T])
+}
+
+// QueueSelectOne queues the query of SelectOne, its result is ErrNotFound without a row.
+func QueueSelectOne[T any](b *Batch, tableName string, options ...SelectOption) *BatchResult[T] {
+	sd := buildSelectDataset(tableName, new(T), append(options, WithSelectLimit(1))...)
+	query, args, err := sd.ToSQL()
+	return queue(b, "select", tableName, query, args, err, redactedSelect(sd, reflect.TypeFor[T]()), scanBatchOne[T])
+}
+
+// QueueInsert queues the query of Insert, its result is nil without a returning option.
+func QueueInsert[T any](b *Batch, tableName string, insertValue any, options ...InsertOption) *BatchResult[*T] {
+	q, err := buildInsertDataset(tableName, []any{insertValue}, false, options...)
+	var (
+		query string
+		args  []any
+	)
+	if err == nil {
+		query, args, err = q.ToSQL()
+	}
+	return queue(b, "insert", tableName, query, args, err, redactedInsert(tableName, []any{insertValue}, options), func(rows pgx.Rows) (*T, error) {
+		if !q.ReturnsColumns() {
+			return nil, nil
+		}
+		result, err := scanBatchOne[T](rows)
+		if err != nil {
+			return nil, err
+		}
+		return &result, nil
+	})
+}
+
+// QueueInsertMany queues a single insert of all the values, unlike InsertMany it isn't split to respect the limit on
+// query parameters.
+func QueueInsertMany[T any](b *Batch, tableName string, insertValues []any, options ...InsertOption) *BatchResult[[]T] {
+	query, args, err := BuildInsert(tableName, insertValues, options...)
+	return queue(b, "insert many", tableName, query, args, err, redactedInsert(tableName, insertValues, options), scanBatchAll[T])
+}
+
+// QueueUpdate queues the query of Update.
+func QueueUpdate[T any](b *Batch, tableName string, updateValue any, options ...UpdateOption) *BatchResult[[]T] {
+	query, args, err := BuildUpdate(tableName, updateValue, options...)
+	return queue(b, "update", tableName, query, args, err, redactedUpdate(func() (*goqu.UpdateDataset, error) {
+		return buildUpdate(tableName, updateValue, true, options...)
+	}, reflect.TypeFor[T](), reflect.TypeOf(updateValue)), scanBatchAll[T])
+}
+
+// QueueDelete queues the query of Delete.
+func QueueDelete[T any](b *Batch, tableName string, options ...DeleteOption) *BatchResult[[]T] {
+	dd := buildDeleteDataset(tableName, options...)
+	query, args, err := dd.ToSQL()
+	return queue(b, "delete", tableName, query, args, err, redactedDelete(dd, reflect.TypeFor[T]()), scanBatchAll[T])
+}
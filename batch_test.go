@@ -0,0 +1,144 @@
+package goqux
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBatcher runs the queries of the goqux batch one at a time with the querier, as pgx.Batch doesn't expose them.
+type fakeBatcher struct {
+	querier Querier
+	batch   *Batch
+	sent    int
+}
+
+func (f *fakeBatcher) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	f.sent++
+	return &fakeBatchResults{ctx: ctx, querier: f.querier, queued: f.batch.queued}
+}
+
+type fakeBatchResults struct {
+	ctx     context.Context
+	querier Querier
+	queued  []batchQuery
+}
+
+func (r *fakeBatchResults) Exec() (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("not supported")
+}
+
+func (r *fakeBatchResults) Query() (pgx.Rows, error) {
+	q := r.queued[0]
+	r.queued = r.queued[1:]
+	return r.querier.Query(r.ctx, q.query, q.args...)
+}
+
+func (r *fakeBatchResults) QueryRow() pgx.Row {
+	return nil
+}
+
+func (r *fakeBatchResults) Close() error {
+	return nil
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDriver{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{int64(1), "a"}},
+	}
+	b := NewBatch()
+	selected := QueueSelect[querierModel](b, "models")
+	selectedOne := QueueSelectOne[querierModel](b, "models", WithSelectFilters(Column("models", "id").Eq(1)))
+	inserted := QueueInsert[querierModel](b, "models", querierModel{ID: 2, Name: "b"})
+	insertedReturning := QueueInsert[querierModel](b, "models", querierModel{ID: 2, Name: "b"}, WithInsertReturningAll())
+	insertedMany := QueueInsertMany[querierModel](b, "models", []any{querierModel{ID: 3, Name: "c"}}, WithInsertReturningAll())
+	updated := QueueUpdate[querierModel](b, "models", querierModel{Name: "d"}, WithUpdateFilters(Column("models", "id").Eq(1)), WithUpdateReturningAll())
+	deleted := QueueDelete[querierModel](b, "models", WithDeleteFilters(Column("models", "id").Eq(1)), WithDeleteReturningAll())
+	queried := QueueQuery[querierModel](b, `SELECT * FROM "models" WHERE "id" = $1`, 1)
+	require.Equal(t, 8, b.Len())
+
+	_, err := selected.Result()
+	require.ErrorIs(t, err, ErrBatchNotSent)
+
+	batcher := &fakeBatcher{querier: newFakeSQLQuerier(t, d), batch: b}
+	require.NoError(t, b.Send(ctx, batcher))
+	assert.Equal(t, 1, batcher.sent)
+	assert.Equal(t, []string{
+		`SELECT "models"."id", "models"."name" FROM "models"`,
+		`SELECT "models"."id", "models"."name" FROM "models" WHERE ("models"."id" = $1) LIMIT $2`,
+		`INSERT INTO "models" ("id", "name") VALUES ($1, $2)`,
+		`INSERT INTO "models" ("id", "name") VALUES ($1, $2) RETURNING *`,
+		`INSERT INTO "models" ("id", "name") VALUES ($1, $2) RETURNING *`,
+		`UPDATE "models" SET "name"=$1 WHERE ("models"."id" = $2) RETURNING *`,
+		`DELETE FROM "models" WHERE ("models"."id" = $1) RETURNING *`,
+		`SELECT * FROM "models" WHERE "id" = $1`,
+	}, d.queries)
+
+	expected := querierModel{ID: 1, Name: "a"}
+	for _, r := range []*BatchResult[[]querierModel]{selected, insertedMany, updated, deleted, queried} {
+		results, err := r.Result()
+		require.NoError(t, err)
+		assert.Equal(t, []querierModel{expected}, results)
+	}
+	result, err := selectedOne.Result()
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	insertResult, err := inserted.Result()
+	require.NoError(t, err)
+	assert.Nil(t, insertResult)
+	insertResult, err = insertedReturning.Result()
+	require.NoError(t, err)
+	assert.Equal(t, &expected, insertResult)
+
+	require.ErrorContains(t, b.Send(ctx, batcher), "already sent")
+}
+
+func TestBatchErrors(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+
+	t.Run("query_error", func(t *testing.T) {
+		d := &fakeDriver{
+			columns:   []string{"id", "name"},
+			queryErrs: []error{nil, errFailed},
+		}
+		b := NewBatch()
+		selectedOne := QueueSelectOne[querierModel](b, "models")
+		deleted := QueueDelete[querierModel](b, "models")
+		err := b.Send(ctx, &fakeBatcher{querier: newFakeSQLQuerier(t, d), batch: b})
+		var queryErr *QueryError
+		require.ErrorAs(t, err, &queryErr)
+		assert.Equal(t, "select", queryErr.Op)
+		_, err = selectedOne.Result()
+		require.ErrorIs(t, err, ErrNotFound)
+		_, err = deleted.Result()
+		require.ErrorIs(t, err, errFailed)
+	})
+
+	t.Run("build_error", func(t *testing.T) {
+		type invalidModel struct {
+			Name string `goqux:"skip_selct"`
+		}
+		d := &fakeDriver{}
+		b := NewBatch()
+		selected := QueueSelect[querierModel](b, "models")
+		inserted := QueueInsert[querierModel](b, "models", invalidModel{})
+		batcher := &fakeBatcher{querier: newFakeSQLQuerier(t, d), batch: b}
+		err := b.Send(ctx, batcher)
+		require.ErrorIs(t, err, ErrInvalidTag)
+		assert.Zero(t, batcher.sent)
+		_, insertErr := inserted.Result()
+		assert.Equal(t, err, insertErr)
+		_, err = selected.Result()
+		require.ErrorIs(t, err, ErrBatchNotSent)
+		require.ErrorIs(t, err, insertErr)
+	})
+}
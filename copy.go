@@ -0,0 +1,108 @@
+package goqux
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CopyFromer runs a COPY FROM, it is implemented by *pgx.Conn, *pgxpool.Pool and pgx.Tx.
+type CopyFromer interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// CopyInsert inserts the values with COPY FROM, which is much faster than InsertMany for many rows and isn't limited by the
+// number of query parameters. Values are encoded like Insert, the columns are taken from the first value and all the values
+// must encode the same columns, so omitempty fields must be set on all of them or on none. Returns the number of rows copied.
+func CopyInsert[T any](ctx context.Context, db CopyFromer, tableName string, values []T) (int64, error) {
+	return CopyInsertSeq(ctx, db, tableName, slices.Values(values))
+}
+
+// CopyInsertSeq is like CopyInsert for the values of an iterator, which are encoded and streamed one at a time.
+func CopyInsertSeq[T any](ctx context.Context, db CopyFromer, tableName string, values iter.Seq[T]) (int64, error) {
+	next, stop := iter.Pull(values)
+	defer stop()
+	first, ok := next()
+	if !ok {
+		return 0, nil
+	}
+	encoded, err := encodeValues(first, skipInsert, false)
+	if err != nil {
+		return 0, err
+	}
+	columns := slices.Sorted(maps.Keys(encoded))
+	src := &copySource[T]{next: next, columns: columns, first: encoded}
+	count, err := db.CopyFrom(ctx, pgx.Identifier(strings.Split(tableName, ".")), columns, src)
+	if err != nil {
+		query := fmt.Sprintf("COPY %s (%s) FROM STDIN", tableName, strings.Join(columns, ", "))
+		return count, newQueryError("copy", tableName, query, nil, nil, err)
+	}
+	return count, nil
+}
+
+// copySource encodes the values of a CopyInsert as they are copied.
+type copySource[T any] struct {
+	next    func() (T, bool)
+	columns []string
+	first   map[string]SQLValuer
+	row     int
+	values  []any
+	err     error
+}
+
+func (s *copySource[T]) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	var encoded map[string]SQLValuer
+	if s.first != nil {
+		encoded, s.first = s.first, nil
+	} else {
+		value, ok := s.next()
+		if !ok {
+			return false
+		}
+		encoded, s.err = encodeValues(value, skipInsert, false)
+		if s.err != nil {
+			return false
+		}
+	}
+	s.row++
+	if len(encoded) != len(s.columns) {
+		s.err = fmt.Errorf("goqux: row %d has %d columns, expected the %d columns of the first row", s.row, len(encoded), len(s.columns))
+		return false
+	}
+	s.values = make([]any, len(s.columns))
+	for i, c := range s.columns {
+		v, ok := encoded[c]
+		if !ok {
+			s.err = fmt.Errorf("goqux: row %d is missing column %s of the first row", s.row, c)
+			return false
+		}
+		s.values[i] = copyValue(v)
+	}
+	return true
+}
+
+func (s *copySource[T]) Values() ([]any, error) {
+	return s.values, nil
+}
+
+func (s *copySource[T]) Err() error {
+	return s.err
+}
+
+// copyValue returns the value to copy, pgx encodes the Go types itself according to the column types, so only the
+// conversions of SQLValuer that pgx doesn't do are applied.
+func copyValue(v SQLValuer) any {
+	if id, ok := v.V.(uuid.UUID); ok && id == uuid.Nil {
+		return nil
+	}
+	return v.V
+}
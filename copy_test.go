@@ -0,0 +1,78 @@
+package goqux
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingCopyFromer drains the copy source, recording its rows.
+type recordingCopyFromer struct {
+	tableName pgx.Identifier
+	columns   []string
+	rows      [][]any
+}
+
+func (c *recordingCopyFromer) CopyFrom(_ context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	c.tableName = tableName
+	c.columns = columnNames
+	for rowSrc.Next() {
+		values, err := rowSrc.Values()
+		if err != nil {
+			return 0, err
+		}
+		c.rows = append(c.rows, values)
+	}
+	return int64(len(c.rows)), rowSrc.Err()
+}
+
+type copyModel struct {
+	ID         int64 `goqux:"skip_insert"`
+	Name       string
+	Nickname   string `db:"nickname,omitempty"`
+	ExternalID uuid.UUID
+	CreatedAt  time.Time `goqux:"now_utc"`
+}
+
+func TestCopyInsert(t *testing.T) {
+	ctx := context.Background()
+	db := &recordingCopyFromer{}
+	count, err := CopyInsert(ctx, db, "billing.users", []copyModel{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, pgx.Identifier{"billing", "users"}, db.tableName)
+	assert.Equal(t, []string{"created_at", "external_id", "name"}, db.columns)
+	require.Len(t, db.rows, 2)
+	for i, name := range []string{"a", "b"} {
+		assert.IsType(t, time.Time{}, db.rows[i][0])
+		assert.Nil(t, db.rows[i][1])
+		assert.Equal(t, name, db.rows[i][2])
+	}
+
+	db = &recordingCopyFromer{}
+	count, err = CopyInsertSeq(ctx, db, "users", slices.Values([]copyModel{{Name: "a", Nickname: "a"}}))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, []string{"created_at", "external_id", "name", "nickname"}, db.columns)
+
+	count, err = CopyInsert(ctx, &recordingCopyFromer{}, "users", []copyModel{})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestCopyInsertInconsistentColumns(t *testing.T) {
+	ctx := context.Background()
+	_, err := CopyInsert(ctx, &recordingCopyFromer{}, "users", []copyModel{{Name: "a", Nickname: "a"}, {Name: "b"}})
+	assert.ErrorContains(t, err, "row 2 has 3 columns, expected the 4 columns of the first row")
+
+	_, err = CopyInsert(ctx, &recordingCopyFromer{}, "users", []map[string]any{{"name": "a"}, {"nickname": "b"}})
+	assert.ErrorContains(t, err, "row 2 is missing column name of the first row")
+	var queryErr *QueryError
+	assert.ErrorAs(t, err, &queryErr)
+}
@@ -0,0 +1,108 @@
+package goqux
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor can't be decoded, doesn't match the pagination options
+// or its signature is invalid.
+var ErrInvalidCursor = errors.New("goqux: invalid cursor")
+
+// cursor is the serialized position of a Paginator, Values and Before are the keyset values of the last and first rows
+// of the current page, Offset and PageOffset are the offsets of the next and current pages for offset pagination
+// and Page is the number of the current page.
+type cursor struct {
+	Values     []json.RawMessage `json:"v,omitempty"`
+	Before     []json.RawMessage `json:"b,omitempty"`
+	Offset     uint              `json:"o,omitempty"`
+	PageOffset uint              `json:"p,omitempty"`
+	Page       uint              `json:"n,omitempty"`
+}
+
+// encodeCursor encodes the cursor as base64 JSON, if key is given the payload is followed by its HMAC-SHA256 signature.
+func encodeCursor(c cursor, key []byte) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("goqux: failed to encode cursor: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(payload)
+	if len(key) == 0 {
+		return token, nil
+	}
+	return token + "." + base64.RawURLEncoding.EncodeToString(signCursor(payload, key)), nil
+}
+
+func decodeCursor(token string, key []byte) (cursor, error) {
+	var c cursor
+	encodedPayload, encodedSignature, signed := strings.Cut(token, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return c, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	if len(key) > 0 {
+		signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+		if !signed || err != nil || !hmac.Equal(signature, signCursor(payload, key)) {
+			return c, fmt.Errorf("%w: signature mismatch", ErrInvalidCursor)
+		}
+	}
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return c, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	return c, nil
+}
+
+func signCursor(payload, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func marshalCursorValues(values []any) ([]json.RawMessage, error) {
+	if values == nil {
+		return nil, nil
+	}
+	raw := make([]json.RawMessage, len(values))
+	for i, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("goqux: failed to encode cursor value: %w", err)
+		}
+		raw[i] = b
+	}
+	return raw, nil
+}
+
+// unmarshalCursorValues decodes the raw keyset values into the types of the keyset fields of T,
+// so values such as time.Time are passed to the query with their original type.
+func unmarshalCursorValues[T any](raw []json.RawMessage, keyset []KeySetColumn) ([]any, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	if len(raw) != len(keyset) {
+		return nil, fmt.Errorf("%w: expected %d keyset values, got %d", ErrInvalidCursor, len(keyset), len(raw))
+	}
+	metadata, err := getStructMetadata(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(raw))
+	for i, c := range keyset {
+		f, ok := metadata.field(c.Name)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown keyset field %s", ErrInvalidCursor, c.Name)
+		}
+		v := reflect.New(f.typ)
+		if err := json.Unmarshal(raw[i], v.Interface()); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+		}
+		values[i] = keySetValue(v.Elem())
+	}
+	return values, nil
+}
@@ -0,0 +1,103 @@
+package goqux
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cursorModel struct {
+	ID        int64
+	CreatedAt time.Time
+	Priority  *int
+}
+
+func TestCursorEncoding(t *testing.T) {
+	tableTests := []struct {
+		name          string
+		cursor        cursor
+		encodeKey     []byte
+		decodeKey     []byte
+		tamper        func(token string) string
+		expectedError error
+	}{
+		{
+			name:   "unsigned",
+			cursor: cursor{Values: []json.RawMessage{json.RawMessage(`1`)}},
+		},
+		{
+			name:      "signed",
+			cursor:    cursor{Offset: 20},
+			encodeKey: []byte("secret"),
+			decodeKey: []byte("secret"),
+		},
+		{
+			name:          "wrong_key",
+			cursor:        cursor{Offset: 20},
+			encodeKey:     []byte("secret"),
+			decodeKey:     []byte("other"),
+			expectedError: ErrInvalidCursor,
+		},
+		{
+			name:          "unsigned_with_key",
+			cursor:        cursor{Offset: 20},
+			decodeKey:     []byte("secret"),
+			expectedError: ErrInvalidCursor,
+		},
+		{
+			name:      "tampered_payload",
+			cursor:    cursor{Offset: 20},
+			encodeKey: []byte("secret"),
+			decodeKey: []byte("secret"),
+			tamper: func(token string) string {
+				forged, _ := encodeCursor(cursor{Offset: 40}, nil)
+				return forged + token[len(forged):]
+			},
+			expectedError: ErrInvalidCursor,
+		},
+		{
+			name:   "not_base64",
+			cursor: cursor{},
+			tamper: func(token string) string {
+				return "%%%"
+			},
+			expectedError: ErrInvalidCursor,
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := encodeCursor(tt.cursor, tt.encodeKey)
+			require.NoError(t, err)
+			if tt.tamper != nil {
+				token = tt.tamper(token)
+			}
+			c, err := decodeCursor(token, tt.decodeKey)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.cursor, c)
+		})
+	}
+}
+
+func TestCursorValues(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	keyset := []KeySetColumn{KeySetAsc("CreatedAt"), KeySetAsc("Priority").NullsLast(), KeySetAsc("ID")}
+	rowValues, err := keySetValues(cursorModel{ID: 5, CreatedAt: createdAt}, keyset)
+	require.NoError(t, err)
+	raw, err := marshalCursorValues(rowValues)
+	require.NoError(t, err)
+	values, err := unmarshalCursorValues[cursorModel](raw, keyset)
+	require.NoError(t, err)
+	assert.Equal(t, []any{createdAt, nil, int64(5)}, values)
+
+	_, err = unmarshalCursorValues[cursorModel](raw[:1], keyset)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = unmarshalCursorValues[cursorModel](raw[:1], []KeySetColumn{KeySetAsc("Missing")})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
@@ -26,10 +26,32 @@ func WithDeleteReturningAll() DeleteOption {
 }
 
 func BuildDelete(tableName string, options ...DeleteOption) (string, []any, error) {
-	table := goqu.T(tableName)
+	return buildDeleteDataset(tableName, options...).ToSQL()
+}
+
+func buildDeleteDataset(tableName string, options ...DeleteOption) *goqu.DeleteDataset {
+	table := tableIdentifier(tableName)
 	deleteQuery := goqu.Delete(table).WithDialect(defaultDialect)
 	for _, o := range options {
 		deleteQuery = o(table, deleteQuery)
 	}
-	return deleteQuery.ToSQL()
+	return deleteQuery
+}
+
+// BuildDeleteByPK builds a delete query for the row of T with the given primary key, key is either a struct with the
+// goqux:"pk" fields set, or the value of the single primary key of T.
+func BuildDeleteByPK[T any](tableName string, key any, options ...DeleteOption) (string, []any, error) {
+	dd, err := buildDeleteByPKDataset[T](tableName, key, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	return dd.ToSQL()
+}
+
+func buildDeleteByPKDataset[T any](tableName string, key any, options ...DeleteOption) (*goqu.DeleteDataset, error) {
+	filters, err := primaryKeyFilters[T](tableIdentifier(tableName), key)
+	if err != nil {
+		return nil, err
+	}
+	return buildDeleteDataset(tableName, append(options, WithDeleteFilters(filters...))...), nil
 }
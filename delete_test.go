@@ -62,3 +62,18 @@ func TestBuildDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildDeleteByPK(t *testing.T) {
+	query, args, err := goqux.BuildDeleteByPK[pkModel]("pk_models", 5, goqux.WithDeleteReturningAll())
+	assert.NoError(t, err)
+	assert.Equal(t, `DELETE FROM "pk_models" WHERE ("pk_models"."id" = $1) RETURNING *`, query)
+	assert.Equal(t, []interface{}{int64(5)}, args)
+
+	query, args, err = goqux.BuildDeleteByPK[compositePKModel]("pk_models", compositePKModel{TenantID: 1, ID: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, `DELETE FROM "pk_models" WHERE (("pk_models"."tenant" = $1) AND ("pk_models"."id" = $2))`, query)
+	assert.Equal(t, []interface{}{int64(1), int64(5)}, args)
+
+	_, _, err = goqux.BuildDeleteByPK[deleteModel]("delete_models", 5)
+	assert.ErrorIs(t, err, goqux.ErrMissingPrimaryKey)
+}
@@ -0,0 +1,102 @@
+package goqux
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrNotFound is returned when a query expected a row but found none, i.e. SelectOne or SelectByPK.
+	ErrNotFound = errors.New("goqux: not found")
+	// ErrUniqueViolation is returned when a query violates a unique constraint (SQLSTATE 23505).
+	ErrUniqueViolation = errors.New("goqux: unique violation")
+	// ErrForeignKeyViolation is returned when a query violates a foreign key constraint (SQLSTATE 23503).
+	ErrForeignKeyViolation = errors.New("goqux: foreign key violation")
+	// ErrCheckViolation is returned when a query violates a check constraint (SQLSTATE 23514).
+	ErrCheckViolation = errors.New("goqux: check violation")
+	// ErrNotNullViolation is returned when a query violates a not null constraint (SQLSTATE 23502).
+	ErrNotNullViolation = errors.New("goqux: not null violation")
+)
+
+var constraintErrors = map[string]error{
+	"23505": ErrUniqueViolation,
+	"23503": ErrForeignKeyViolation,
+	"23514": ErrCheckViolation,
+	"23502": ErrNotNullViolation,
+}
+
+// ConstraintError is a constraint violation reported by Postgres, errors.Is matches it with its Kind,
+// and errors.As with the *pgconn.PgError or *pq.Error it wraps.
+//
+//	var constraintErr *goqux.ConstraintError
+//	if errors.As(err, &constraintErr) && errors.Is(err, goqux.ErrUniqueViolation) {
+//		log.Printf("duplicate %s", constraintErr.Constraint)
+//	}
+type ConstraintError struct {
+	// Kind is one of ErrUniqueViolation, ErrForeignKeyViolation, ErrCheckViolation or ErrNotNullViolation.
+	Kind       error
+	Code       string
+	Schema     string
+	Table      string
+	Column     string
+	Constraint string
+	Detail     string
+	Err        error
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Err)
+}
+
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// translateError maps the no rows errors to ErrNotFound and the constraint violations of pgx and lib/pq to a ConstraintError,
+// other errors are returned as is.
+func translateError(err error) error {
+	if err == nil || errors.Is(err, ErrNotFound) || errors.As(err, new(*ConstraintError)) {
+		return err
+	}
+	if notFound(err) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if kind, ok := constraintErrors[pgErr.Code]; ok {
+			return &ConstraintError{
+				Kind:       kind,
+				Code:       pgErr.Code,
+				Schema:     pgErr.SchemaName,
+				Table:      pgErr.TableName,
+				Column:     pgErr.ColumnName,
+				Constraint: pgErr.ConstraintName,
+				Detail:     pgErr.Detail,
+				Err:        err,
+			}
+		}
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if kind, ok := constraintErrors[string(pqErr.Code)]; ok {
+			return &ConstraintError{
+				Kind:       kind,
+				Code:       string(pqErr.Code),
+				Schema:     pqErr.Schema,
+				Table:      pqErr.Table,
+				Column:     pqErr.Column,
+				Constraint: pqErr.Constraint,
+				Detail:     pqErr.Detail,
+				Err:        err,
+			}
+		}
+	}
+	return err
+}
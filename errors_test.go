@@ -0,0 +1,76 @@
+package goqux
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateError(t *testing.T) {
+	errFailed := errors.New("failed")
+	pgErr := &pgconn.PgError{Code: "42P01"}
+	tableTests := []struct {
+		name               string
+		err                error
+		expectedErr        error
+		expectedConstraint *ConstraintError
+	}{
+		{name: "nil", err: nil, expectedErr: nil},
+		{name: "other", err: errFailed, expectedErr: errFailed},
+		{name: "pgx_no_rows", err: fmt.Errorf("scanning one: %w", pgx.ErrNoRows), expectedErr: ErrNotFound},
+		{name: "sql_no_rows", err: sql.ErrNoRows, expectedErr: ErrNotFound},
+		{
+			name:        "pgx_unique_violation",
+			err:         &pgconn.PgError{Code: "23505", SchemaName: "public", TableName: "users", ConstraintName: "users_email_key", Detail: "Key (email)=(a) already exists."},
+			expectedErr: ErrUniqueViolation,
+			expectedConstraint: &ConstraintError{Kind: ErrUniqueViolation, Code: "23505", Schema: "public", Table: "users",
+				Constraint: "users_email_key", Detail: "Key (email)=(a) already exists."},
+		},
+		{
+			name:               "pgx_foreign_key_violation",
+			err:                &pgconn.PgError{Code: "23503", TableName: "posts", ConstraintName: "posts_user_id_fkey"},
+			expectedErr:        ErrForeignKeyViolation,
+			expectedConstraint: &ConstraintError{Kind: ErrForeignKeyViolation, Code: "23503", Table: "posts", Constraint: "posts_user_id_fkey"},
+		},
+		{
+			name:               "pq_check_violation",
+			err:                &pq.Error{Code: "23514", Table: "users", Constraint: "users_age_check"},
+			expectedErr:        ErrCheckViolation,
+			expectedConstraint: &ConstraintError{Kind: ErrCheckViolation, Code: "23514", Table: "users", Constraint: "users_age_check"},
+		},
+		{
+			name:               "pq_not_null_violation",
+			err:                &pq.Error{Code: "23502", Table: "users", Column: "email"},
+			expectedErr:        ErrNotNullViolation,
+			expectedConstraint: &ConstraintError{Kind: ErrNotNullViolation, Code: "23502", Table: "users", Column: "email"},
+		},
+		{name: "other_pg_error", err: pgErr, expectedErr: pgErr},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := translateError(tt.err)
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.expectedConstraint == nil {
+				assert.False(t, errors.As(err, new(*ConstraintError)))
+				return
+			}
+			var constraintErr *ConstraintError
+			require.ErrorAs(t, err, &constraintErr)
+			tt.expectedConstraint.Err = tt.err
+			assert.Equal(t, tt.expectedConstraint, constraintErr)
+			assert.Same(t, err, translateError(err))
+		})
+	}
+}
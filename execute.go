@@ -2,214 +2,269 @@ package goqux
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 
 	"github.com/doug-martin/goqu/v9"
-	"github.com/georgysavva/scany/v2/pgxscan"
-	"github.com/iancoleman/strcase"
 )
 
-type PaginationOptions struct {
-	// PageSize per page (default: 10)
-	PageSize uint
-	// Use columns for key filtering, this will add a WithKeySet option to the query,
-	// keys aren't validated, so make sure the names are correct or query will fail
-	// if KeySet isn't set, pagination will use offset instead.
-	KeySet []string
-}
-
-// PageIterator is a function that returns a page of results and a boolean indicating if there should be a next page or to stop iterating.
-type PageIterator[T any] func(p *Paginator[T]) ([]T, bool, error)
-
-// Paginator allows to paginate over result set of T
-type Paginator[T any] struct {
-	hasNext  bool
-	iterator PageIterator[T]
-	offset   uint
-	values   []any
-	stop     bool
-}
+// ErrStopIteration can be returned from a SelectEach or ForEach callback to stop iterating over the rows without failing.
+var ErrStopIteration = errors.New("goqux: stop iteration")
 
-func NewPaginator[T any](iterator PageIterator[T]) *Paginator[T] {
-	return &Paginator[T]{
-		hasNext:  true,
-		iterator: iterator,
-		offset:   0,
-		values:   nil,
-		stop:     false,
+func Select[T any](ctx context.Context, querier Querier, tableName string, options ...SelectOption) ([]T, error) {
+	sd := buildSelectDataset(tableName, new(T), options...)
+	query, args, err := sd.ToSQL()
+	if err != nil {
+		return nil, err
 	}
+	results := make([]T, 0)
+	if err := executorOf(querier).Select(ctx, &results, query, args...); err != nil {
+		return nil, newQueryError("select", tableName, query, args, redactedSelect(sd, reflect.TypeFor[T]()), err)
+	}
+	return results, nil
 }
 
-func (p *Paginator[T]) HasMorePages() bool {
-	return p.hasNext && !p.stop
+func SelectOne[T any](ctx context.Context, querier Querier, tableName string, options ...SelectOption) (T, error) {
+	var result T
+	sd := buildSelectDataset(tableName, new(T), append(options, WithSelectLimit(1))...)
+	query, args, err := sd.ToSQL()
+	if err != nil {
+		return result, err
+	}
+	if err := executorOf(querier).Get(ctx, &result, query, args...); err != nil {
+		return result, newQueryError("select", tableName, query, args, redactedSelect(sd, reflect.TypeFor[T]()), err)
+	}
+	return result, nil
 }
 
-func (p *Paginator[T]) NextPage() ([]T, error) {
-	data, shouldStop, err := p.iterator(p)
-	if shouldStop {
-		p.stop = true
+// SelectEach runs the select query and scans the rows one at a time into T, calling fn for each row,
+// so only a single row is held in memory. Return ErrStopIteration from fn to stop early, any other error is returned as is.
+func SelectEach[T any](ctx context.Context, querier Querier, tableName string, fn func(T) error, options ...SelectOption) error {
+	sd := buildSelectDataset(tableName, new(T), options...)
+	query, args, err := sd.ToSQL()
+	if err != nil {
+		return err
 	}
-	return data, err
+	return forEachRow(ctx, querier, tableName, query, args, redactedSelect(sd, reflect.TypeFor[T]()), fn)
 }
 
-func Select[T any](ctx context.Context, querier pgxscan.Querier, tableName string, options ...SelectOption) ([]T, error) {
-	query, args, err := BuildSelect(tableName, new(T), options...)
+// ForEach is like SelectEach for any select dataset.
+func ForEach[T any](ctx context.Context, querier Querier, sd *goqu.SelectDataset, fn func(T) error) error {
+	query, args, err := sd.ToSQL()
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("goqux: failed to build select query: %w", err)
 	}
-	results := make([]T, 0)
-	if err := pgxscan.Select(ctx, querier, &results, query, args...); err != nil {
-		return nil, fmt.Errorf("goqux: failed to select: %w", err)
+	return forEachRow(ctx, querier, "", query, args, redactedSelect(sd, reflect.TypeFor[T]()), fn)
+}
+
+func forEachRow[T any](ctx context.Context, querier Querier, tableName string, query string, args []any, redacted redactedQuery, fn func(T) error) error {
+	var fnErr error
+	err := executorOf(querier).Each(ctx, query, args, func(scan func(dst any) error) error {
+		var row T
+		if err := scan(&row); err != nil {
+			fnErr = newQueryError("scan", tableName, query, args, redacted, err)
+			return fnErr
+		}
+		fnErr = fn(row)
+		return fnErr
+	})
+	switch {
+	case fnErr != nil && errors.Is(fnErr, ErrStopIteration):
+		return nil
+	case fnErr != nil:
+		return fnErr
+	case err != nil:
+		return newQueryError("select", tableName, query, args, redacted, err)
 	}
-	return results, nil
+	return nil
 }
 
-func SelectOne[T any](ctx context.Context, querier pgxscan.Querier, tableName string, options ...SelectOption) (T, error) {
+// SelectByPK selects the row of T with the given primary key, key is either a struct with the goqux:"pk" fields set,
+// or the value of the single primary key of T.
+func SelectByPK[T any](ctx context.Context, querier Querier, tableName string, key any, options ...SelectOption) (T, error) {
 	var result T
-	query, args, err := BuildSelect(tableName, new(T), append(options, WithSelectLimit(1))...)
+	sd, err := buildSelectByPKDataset[T](tableName, key, options...)
+	if err != nil {
+		return result, err
+	}
+	query, args, err := sd.ToSQL()
 	if err != nil {
 		return result, err
 	}
-	if err := pgxscan.Get(ctx, querier, &result, query, args...); err != nil {
-		return result, fmt.Errorf("goqux: failed to select: %w", err)
+	if err := executorOf(querier).Get(ctx, &result, query, args...); err != nil {
+		return result, newQueryError("select", tableName, query, args, redactedSelect(sd, reflect.TypeFor[T]()), err)
 	}
 	return result, nil
 }
 
-func SelectPagination[T any](ctx context.Context, querier pgxscan.Querier, tableName string, paginationOptions *PaginationOptions, options ...SelectOption) (*Paginator[T], error) {
-	if paginationOptions == nil {
-		paginationOptions = &PaginationOptions{
-			PageSize: 10,
-		}
+func Delete[T any](ctx context.Context, querier Querier, tableName string, options ...DeleteOption) ([]T, error) {
+	dd := buildDeleteDataset(tableName, options...)
+	query, args, err := dd.ToSQL()
+	if err != nil {
+		return nil, err
 	}
-	originalOptions := options
-	return NewPaginator(func(p *Paginator[T]) ([]T, bool, error) {
-		if paginationOptions.KeySet != nil {
-			//nolint:gocritic
-			options = append(originalOptions, WithKeySet(paginationOptions.KeySet, p.values))
-		} else {
-			//nolint:gocritic
-			options = append(originalOptions, WithSelectOffset(p.offset))
-		}
-		results, err := Select[T](ctx, querier, tableName, append(options, WithSelectLimit(paginationOptions.PageSize))...)
-		if err != nil {
-			return nil, false, fmt.Errorf("goqux: failed to select: %w", err)
-		}
-		if len(results) == 0 || len(results) < int(paginationOptions.PageSize) {
-			p.hasNext = false
-			return results, false, nil
-		}
-		if len(paginationOptions.KeySet) > 0 {
-			var values = make([]any, len(results))
-			lastResult := results[len(results)-1]
-			for i, c := range paginationOptions.KeySet {
-				values[i] = reflect.ValueOf(lastResult).FieldByName(c).Interface()
-			}
-			p.values = values
-		} else {
-			p.offset += paginationOptions.PageSize
-		}
-		return results, false, nil
-	}), nil
+	results := make([]T, 0)
+	if err := executorOf(querier).Select(ctx, &results, query, args...); err != nil {
+		return nil, newQueryError("delete", tableName, query, args, redactedDelete(dd, reflect.TypeFor[T]()), err)
+	}
+	return results, nil
 }
 
-// QueryKeySetPagination is a helper function to paginate over a query using keyset pagination.
-func QueryKeySetPagination[T any](ctx context.Context, querier pgxscan.Querier, sd *goqu.SelectDataset, pageSize uint, keyset []string) (*Paginator[T], error) {
-	if len(keyset) == 0 {
-		return nil, fmt.Errorf("goqux: keyset is required for pagination")
+// DeleteByPK deletes the row of T with the given primary key, see SelectByPK for the key.
+func DeleteByPK[T any](ctx context.Context, querier Querier, tableName string, key any, options ...DeleteOption) ([]T, error) {
+	dd, err := buildDeleteByPKDataset[T](tableName, key, options...)
+	if err != nil {
+		return nil, err
 	}
-	paginationOptions := &PaginationOptions{
-		PageSize: pageSize,
-		KeySet:   keyset,
+	query, args, err := dd.ToSQL()
+	if err != nil {
+		return nil, err
 	}
-	return NewPaginator(func(p *Paginator[T]) ([]T, bool, error) {
-		sd = sd.Limit(paginationOptions.PageSize).ClearOffset().ClearOrder()
-		if p.values == nil {
-			for _, c := range keyset {
-				sd = sd.OrderAppend(goqu.C(strcase.ToSnake(c)).Asc())
-			}
-		} else {
-			for i, c := range keyset {
-				sd = sd.Where(goqu.C(strcase.ToSnake(c)).Gt(p.values[i]))
-				sd = sd.OrderAppend(goqu.C(strcase.ToSnake(c)).Asc())
-			}
-		}
-
-		query, args, err := sd.ToSQL()
-		if err != nil {
-			return nil, false, fmt.Errorf("goqux: failed to build select query: %w", err)
-		}
-		rows, err := querier.Query(ctx, query, args...)
-		if err != nil {
-			return nil, false, fmt.Errorf("querier: failed to select: %w", err)
-		}
-
-		results := make([]T, 0)
-		if err := pgxscan.ScanAll(&results, rows); err != nil {
-			return nil, false, fmt.Errorf("dbscan: failed to scan: %w", err)
-		}
-		if len(results) == 0 || len(results) < int(paginationOptions.PageSize) {
-			p.hasNext = false
-			return results, false, nil
-		}
-		var values = make([]any, len(results))
-		lastResult := results[len(results)-1]
-		for i, c := range paginationOptions.KeySet {
-			values[i] = reflect.ValueOf(lastResult).FieldByName(c).Interface()
-		}
-		p.values = values
-		return results, false, nil
-	}), nil
+	results := make([]T, 0)
+	if err := executorOf(querier).Select(ctx, &results, query, args...); err != nil {
+		return nil, newQueryError("delete", tableName, query, args, redactedDelete(dd, reflect.TypeFor[T]()), err)
+	}
+	return results, nil
 }
 
-func Delete[T any](ctx context.Context, querier pgxscan.Querier, tableName string, options ...DeleteOption) ([]T, error) {
-	query, args, err := BuildDelete(tableName, options...)
+func Update[T any](ctx context.Context, querier Querier, tableName string, updateValue any, options ...UpdateOption) ([]T, error) {
+	query, args, err := BuildUpdate(tableName, updateValue, options...)
 	if err != nil {
 		return nil, err
 	}
 	results := make([]T, 0)
-	if err := pgxscan.Select(ctx, querier, &results, query, args...); err != nil {
-		return nil, fmt.Errorf("goqux: failed to delete: %w", err)
+	if err := executorOf(querier).Select(ctx, &results, query, args...); err != nil {
+		return nil, newQueryError("update", tableName, query, args, redactedUpdate(func() (*goqu.UpdateDataset, error) {
+			return buildUpdate(tableName, updateValue, true, options...)
+		}, reflect.TypeFor[T](), reflect.TypeOf(updateValue)), err)
 	}
 	return results, nil
 }
 
-func Update[T any](ctx context.Context, querier pgxscan.Querier, tableName string, updateValue any, options ...UpdateOption) ([]T, error) {
-	query, args, err := BuildUpdate(tableName, updateValue, options...)
+// UpdateByPK updates the row with the primary key of updateValue, setting its non-zero fields except the primary key.
+func UpdateByPK[T any](ctx context.Context, querier Querier, tableName string, updateValue any, options ...UpdateOption) ([]T, error) {
+	query, args, err := BuildUpdateByPK(tableName, updateValue, options...)
 	if err != nil {
 		return nil, err
 	}
 	results := make([]T, 0)
-	if err := pgxscan.Select(ctx, querier, &results, query, args...); err != nil {
-		return nil, fmt.Errorf("goqux: failed to update: %w", err)
+	if err := executorOf(querier).Select(ctx, &results, query, args...); err != nil {
+		return nil, newQueryError("update", tableName, query, args, redactedUpdate(func() (*goqu.UpdateDataset, error) {
+			return buildUpdateByPK(tableName, updateValue, true, options...)
+		}, reflect.TypeFor[T](), reflect.TypeOf(updateValue)), err)
 	}
 	return results, nil
 }
 
-func Insert[T any](ctx context.Context, querier pgxscan.Querier, tableName string, insertValue any, options ...InsertOption) (*T, error) {
-	var result T
-	query, args, err := BuildInsert(tableName, []any{insertValue}, options...)
+// Insert inserts insertValue, returning nil without a returning option, or the returned row with one.
+// ErrNotFound is returned if the insert with a returning option didn't return a row, i.e. on a conflict with ON CONFLICT DO NOTHING.
+func Insert[T any](ctx context.Context, querier Querier, tableName string, insertValue any, options ...InsertOption) (*T, error) {
+	q, err := buildInsertDataset(tableName, []any{insertValue}, false, options...)
+	if err != nil {
+		return nil, err
+	}
+	query, args, err := q.ToSQL()
 	if err != nil {
 		return nil, err
 	}
-	if err := pgxscan.Get(ctx, querier, &result, query, args...); err != nil {
-		if pgxscan.NotFound(err) {
-			return nil, nil
+	if !q.ReturnsColumns() {
+		err := executorOf(querier).Each(ctx, query, args, func(func(dst any) error) error { return nil })
+		if err != nil {
+			return nil, newQueryError("insert", tableName, query, args, redactedInsert(tableName, []any{insertValue}, options), err)
 		}
-		return nil, fmt.Errorf("goqux: failed to insert: %w", err)
+		return nil, nil
+	}
+	var result T
+	if err := executorOf(querier).Get(ctx, &result, query, args...); err != nil {
+		return nil, newQueryError("insert", tableName, query, args, redactedInsert(tableName, []any{insertValue}, options), err)
 	}
 	return &result, nil
 }
 
-func InsertMany[T any](ctx context.Context, querier pgxscan.Querier, tableName string, insertValues []any, options ...InsertOption) ([]T, error) {
+// defaultMaxInsertParams is the maximum number of parameters of a Postgres query.
+const defaultMaxInsertParams = 65535
+
+// InsertManyOptions are the options of InsertManyWithOptions.
+type InsertManyOptions struct {
+	// MaxParams is the maximum number of query parameters of each insert, defaults to 65535, the limit of Postgres.
+	MaxParams uint
+	// Transaction runs the inserts of all the chunks in a single transaction, so either all or none of the values are inserted.
+	Transaction bool
+}
+
+// InsertMany inserts the values, split into as many inserts as needed to respect the Postgres limit on query parameters,
+// see InsertManyWithOptions.
+func InsertMany[T any](ctx context.Context, querier Querier, tableName string, insertValues []any, options ...InsertOption) ([]T, error) {
+	return InsertManyWithOptions[T](ctx, querier, tableName, insertValues, nil, options...)
+}
+
+// InsertManyWithOptions inserts the values in chunks, sized so each insert has at most insertManyOptions.MaxParams parameters.
+// The chunks are inserted in order and the returned rows are concatenated in the same order.
+func InsertManyWithOptions[T any](ctx context.Context, querier Querier, tableName string, insertValues []any, insertManyOptions *InsertManyOptions, options ...InsertOption) ([]T, error) {
+	if insertManyOptions == nil {
+		insertManyOptions = &InsertManyOptions{}
+	}
+	maxParams := insertManyOptions.MaxParams
+	if maxParams == 0 {
+		maxParams = defaultMaxInsertParams
+	}
+	chunks, err := insertChunks(insertValues, maxParams)
+	if err != nil {
+		return nil, err
+	}
+	insertAll := func(querier Querier) ([]T, error) {
+		results := make([]T, 0)
+		for _, chunk := range chunks {
+			chunkResults, err := insertManyChunk[T](ctx, querier, tableName, chunk, options...)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, chunkResults...)
+		}
+		return results, nil
+	}
+	if !insertManyOptions.Transaction || len(chunks) < 2 {
+		return insertAll(querier)
+	}
+	var results []T
+	err = WithTx(ctx, querier, nil, func(tx Querier) error {
+		results, err = insertAll(tx)
+		return err
+	})
+	return results, err
+}
+
+// insertChunks splits the values into chunks with at most maxParams parameters, the parameters of a row are the columns
+// encoded from the first value.
+func insertChunks(values []any, maxParams uint) ([][]any, error) {
+	if len(values) == 0 {
+		return [][]any{values}, nil
+	}
+	encoded, err := encodeValues(values[0], skipInsert, false)
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := len(values)
+	if len(encoded) > 0 {
+		chunkSize = max(1, int(maxParams)/len(encoded))
+	}
+	chunks := make([][]any, 0, (len(values)+chunkSize-1)/chunkSize)
+	for chunk := range slices.Chunk(values, chunkSize) {
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+func insertManyChunk[T any](ctx context.Context, querier Querier, tableName string, insertValues []any, options ...InsertOption) ([]T, error) {
 	query, args, err := BuildInsert(tableName, insertValues, options...)
 	if err != nil {
 		return nil, err
 	}
 	results := make([]T, 0)
-	if err := pgxscan.Select(ctx, querier, &results, query, args...); err != nil {
-		return nil, fmt.Errorf("goqux: failed to insert many: %w", err)
+	if err := executorOf(querier).Select(ctx, &results, query, args...); err != nil {
+		return nil, newQueryError("insert many", tableName, query, args, redactedInsert(tableName, insertValues, options), err)
 	}
 	return results, nil
 }
@@ -2,11 +2,19 @@ package goqux_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/roneli/goqux"
 	"github.com/stretchr/testify/require"
 )
@@ -20,6 +28,12 @@ type User struct {
 	Email    string
 }
 
+type keySetEvent struct {
+	ID        int64     `db:"id" goqux:"pk"`
+	CreatedAt time.Time `db:"created_at"`
+	Priority  *int64    `db:"priority"`
+}
+
 type randomNumbers struct {
 	ID     int64 `db:"id"`
 	Number int64 `db:"number"`
@@ -91,6 +105,73 @@ func TestSelect(t *testing.T) {
 	}
 }
 
+func TestSelectSeq(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	expected, err := goqux.Select[keySetEvent](ctx, conn, "keyset_events", goqux.WithSelectOrder(goqu.C("id").Asc()))
+	require.Nil(t, err)
+	models := make([]keySetEvent, 0)
+	for model, err := range goqux.SelectSeq[keySetEvent](ctx, conn, "keyset_events", goqux.WithSelectOrder(goqu.C("id").Asc())) {
+		require.Nil(t, err)
+		models = append(models, model)
+	}
+	require.Equal(t, expected, models)
+
+	// breaking out of the loop must release the connection for the next query
+	for range goqux.SelectSeq[keySetEvent](ctx, conn, "keyset_events") {
+		break
+	}
+	paginator, err := goqux.SelectPagination[keySetEvent](ctx, conn, "keyset_events", &goqux.PaginationOptions{PageSize: 7, KeySet: []string{"ID"}})
+	require.Nil(t, err)
+	models = make([]keySetEvent, 0)
+	for model, err := range paginator.All() {
+		require.Nil(t, err)
+		models = append(models, model)
+	}
+	require.Equal(t, expected, models)
+}
+
+func TestSelectEach(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	expected, err := goqux.Select[keySetEvent](ctx, conn, "keyset_events", goqux.WithSelectOrder(goqu.C("id").Asc()))
+	require.Nil(t, err)
+	models := make([]keySetEvent, 0)
+	err = goqux.SelectEach(ctx, conn, "keyset_events", func(m keySetEvent) error {
+		models = append(models, m)
+		return nil
+	}, goqux.WithSelectOrder(goqu.C("id").Asc()))
+	require.Nil(t, err)
+	require.Equal(t, expected, models)
+
+	count := 0
+	err = goqux.SelectEach(ctx, conn, "keyset_events", func(m keySetEvent) error {
+		count++
+		if count == 3 {
+			return goqux.ErrStopIteration
+		}
+		return nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, 3, count)
+
+	callbackErr := errors.New("callback failed")
+	err = goqux.ForEach(ctx, conn, goqu.Dialect("postgres").From("keyset_events"), func(m keySetEvent) error {
+		return callbackErr
+	})
+	require.ErrorIs(t, err, callbackErr)
+}
+
 func TestDelete(t *testing.T) {
 	ctx := context.Background()
 	conn, err := pgx.Connect(ctx, testPostgresURI)
@@ -124,6 +205,336 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+type pkUser struct {
+	ID       int64 `db:"id" goqux:"pk,skip_insert"`
+	Username string
+	Password string
+	Email    string
+}
+
+func TestByPK(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	inserted, err := goqux.Insert[pkUser](ctx, conn, "users", pkUser{Username: "pk", Password: "pk", Email: "pk@acme.com"},
+		goqux.WithInsertReturning("id", "username", "password", "email"))
+	require.Nil(t, err)
+	require.NotNil(t, inserted)
+
+	updated, err := goqux.UpdateByPK[pkUser](ctx, conn, "users", pkUser{ID: inserted.ID, Username: "pk_updated"},
+		goqux.WithUpdateReturning("id", "username", "password", "email"))
+	require.Nil(t, err)
+	require.Equal(t, []pkUser{{ID: inserted.ID, Username: "pk_updated", Password: "pk", Email: "pk@acme.com"}}, updated)
+
+	selected, err := goqux.SelectByPK[pkUser](ctx, conn, "users", inserted.ID)
+	require.Nil(t, err)
+	require.Equal(t, updated[0], selected)
+
+	_, err = goqux.DeleteByPK[pkUser](ctx, conn, "users", selected)
+	require.Nil(t, err)
+
+	_, err = goqux.SelectByPK[pkUser](ctx, conn, "users", inserted.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+	require.ErrorIs(t, err, goqux.ErrNotFound)
+}
+
+func TestSQLQuerierExecutions(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	db, err := sql.Open("postgres", testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		require.Nil(t, db.Close())
+	}()
+	querier := goqux.NewSQLQuerier(db)
+
+	expected, err := goqux.Select[keySetEvent](ctx, conn, "keyset_events", goqux.WithSelectOrder(goqu.C("id").Asc()))
+	require.Nil(t, err)
+	results, err := goqux.Select[keySetEvent](ctx, querier, "keyset_events", goqux.WithSelectOrder(goqu.C("id").Asc()))
+	require.Nil(t, err)
+	require.Equal(t, len(expected), len(results))
+	for i := range expected {
+		require.Equal(t, expected[i].ID, results[i].ID)
+		require.True(t, expected[i].CreatedAt.Equal(results[i].CreatedAt))
+		require.Equal(t, expected[i].Priority, results[i].Priority)
+	}
+
+	paginator, err := goqux.SelectPagination[keySetEvent](ctx, querier, "keyset_events", &goqux.PaginationOptions{PageSize: 3, KeySet: []string{"ID"}})
+	require.Nil(t, err)
+	ids := make([]int64, 0, len(expected))
+	for event, err := range paginator.All() {
+		require.Nil(t, err)
+		ids = append(ids, event.ID)
+	}
+	require.Len(t, ids, len(expected))
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.Nil(t, err)
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	txQuerier := goqux.NewSQLQuerier(tx)
+	inserted, err := goqux.Insert[pkUser](ctx, txQuerier, "users", pkUser{Username: "sql", Password: "sql", Email: "sql@acme.com"},
+		goqux.WithInsertReturning("id", "username", "password", "email"))
+	require.Nil(t, err)
+	require.NotNil(t, inserted)
+	_, err = goqux.DeleteByPK[pkUser](ctx, txQuerier, "users", inserted.ID)
+	require.Nil(t, err)
+	_, err = goqux.SelectByPK[pkUser](ctx, txQuerier, "users", inserted.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	var inserted *pkUser
+	err = goqux.WithTx(ctx, conn, &goqux.TxOptions{IsoLevel: pgx.Serializable, MaxRetries: 3}, func(tx goqux.Querier) error {
+		inserted, err = goqux.Insert[pkUser](ctx, tx, "users", pkUser{Username: "tx", Password: "tx", Email: "tx@acme.com"},
+			goqux.WithInsertReturning("id", "username", "password", "email"))
+		return err
+	})
+	require.Nil(t, err)
+	require.NotNil(t, inserted)
+
+	callbackErr := errors.New("callback failed")
+	err = goqux.WithTx(ctx, conn, nil, func(tx goqux.Querier) error {
+		if _, err := goqux.DeleteByPK[pkUser](ctx, tx, "users", inserted.ID); err != nil {
+			return err
+		}
+		return callbackErr
+	})
+	require.ErrorIs(t, err, callbackErr)
+
+	selected, err := goqux.SelectByPK[pkUser](ctx, conn, "users", inserted.ID)
+	require.Nil(t, err)
+	require.Equal(t, *inserted, selected)
+	_, err = goqux.DeleteByPK[pkUser](ctx, conn, "users", inserted.ID)
+	require.Nil(t, err)
+}
+
+func TestWithTxNested(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	insert := func(tx goqux.Querier, username string) error {
+		_, err := goqux.Insert[pkUser](ctx, tx, "users", pkUser{Username: username, Password: "nested", Email: "nested@acme.com"})
+		return err
+	}
+	callbackErr := errors.New("callback failed")
+	err = goqux.WithTx(ctx, conn, nil, func(tx goqux.Querier) error {
+		if err := insert(tx, "nested_outer"); err != nil {
+			return err
+		}
+		err := goqux.WithTx(ctx, tx, nil, func(tx goqux.Querier) error {
+			if err := insert(tx, "nested_inner"); err != nil {
+				return err
+			}
+			err := goqux.WithTx(ctx, tx, nil, func(tx goqux.Querier) error {
+				if err := insert(tx, "nested_rolled_back"); err != nil {
+					return err
+				}
+				return callbackErr
+			})
+			require.ErrorIs(t, err, callbackErr)
+			return goqux.WithTx(ctx, tx, nil, func(tx goqux.Querier) error {
+				return insert(tx, "nested_innermost")
+			})
+		})
+		require.Nil(t, err)
+		return goqux.WithTx(ctx, tx, nil, func(tx goqux.Querier) error {
+			if err := insert(tx, "nested_rolled_back"); err != nil {
+				return err
+			}
+			return callbackErr
+		})
+	})
+	require.ErrorIs(t, err, callbackErr)
+	users, err := goqux.Select[pkUser](ctx, conn, "users", goqux.WithSelectFilters(goqux.Column("users", "password").Eq("nested")))
+	require.Nil(t, err)
+	require.Empty(t, users)
+
+	err = goqux.WithTx(ctx, conn, nil, func(tx goqux.Querier) error {
+		if err := insert(tx, "nested_outer"); err != nil {
+			return err
+		}
+		return goqux.WithTx(ctx, tx, nil, func(tx goqux.Querier) error {
+			if err := insert(tx, "nested_inner"); err != nil {
+				return err
+			}
+			err := goqux.WithTx(ctx, tx, nil, func(tx goqux.Querier) error {
+				if err := insert(tx, "nested_rolled_back"); err != nil {
+					return err
+				}
+				return callbackErr
+			})
+			require.ErrorIs(t, err, callbackErr)
+			return nil
+		})
+	})
+	require.Nil(t, err)
+	users, err = goqux.Select[pkUser](ctx, conn, "users", goqux.WithSelectFilters(goqux.Column("users", "password").Eq("nested")),
+		goqux.WithSelectOrder(goqu.C("id").Asc()))
+	require.Nil(t, err)
+	usernames := make([]string, len(users))
+	for i, u := range users {
+		usernames[i] = u.Username
+	}
+	require.Equal(t, []string{"nested_outer", "nested_inner"}, usernames)
+	_, err = goqux.Delete[pkUser](ctx, conn, "users", goqux.WithDeleteFilters(goqux.Column("users", "password").Eq("nested")))
+	require.Nil(t, err)
+}
+
+func TestConstraintErrors(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	db, err := sql.Open("postgres", testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		require.Nil(t, db.Close())
+	}()
+	type post struct {
+		Title   string
+		Content string
+		UserID  int64
+	}
+	tableTests := []struct {
+		name               string
+		tableName          string
+		value              any
+		expectedErr        error
+		expectedTable      string
+		expectedConstraint string
+	}{
+		{
+			name:               "unique_violation",
+			tableName:          "select_users",
+			value:              pkUser{ID: 1, Username: "admin", Password: "admin", Email: "admin@acme.com"},
+			expectedErr:        goqux.ErrUniqueViolation,
+			expectedTable:      "select_users",
+			expectedConstraint: "select_users_pkey",
+		},
+		{
+			name:               "foreign_key_violation",
+			tableName:          "insert_posts",
+			value:              post{Title: "title", Content: "content", UserID: -1},
+			expectedErr:        goqux.ErrForeignKeyViolation,
+			expectedTable:      "insert_posts",
+			expectedConstraint: "insert_posts_user_id_fkey",
+		},
+		{
+			name:          "not_null_violation",
+			tableName:     "insert_posts",
+			value:         map[string]any{"title": nil, "content": "content", "user_id": 1},
+			expectedErr:   goqux.ErrNotNullViolation,
+			expectedTable: "insert_posts",
+		},
+	}
+	for _, tt := range tableTests {
+		for name, querier := range map[string]goqux.Querier{"pgx": conn, "sql": goqux.NewSQLQuerier(db)} {
+			t.Run(tt.name+"_"+name, func(t *testing.T) {
+				_, err := goqux.Insert[pkUser](ctx, querier, tt.tableName, tt.value)
+				require.ErrorIs(t, err, tt.expectedErr)
+				var constraintErr *goqux.ConstraintError
+				require.ErrorAs(t, err, &constraintErr)
+				require.Equal(t, tt.expectedTable, constraintErr.Table)
+				require.Equal(t, tt.expectedConstraint, constraintErr.Constraint)
+			})
+		}
+	}
+}
+
+type upsertUser struct {
+	ID    int64  `goqux:"pk,skip_insert"`
+	Email string `goqux:"unique"`
+	Name  string
+}
+
+func TestUpsert(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	returning := goqux.WithInsertReturning("id", "email", "name")
+	inserted, err := goqux.Upsert[upsertUser](ctx, conn, "upsert_users", upsertUser{Email: "upsert@acme.com", Name: "first"}, returning)
+	require.Nil(t, err)
+	require.Equal(t, "first", inserted.Name)
+
+	updated, err := goqux.Upsert[upsertUser](ctx, conn, "upsert_users", upsertUser{Email: "upsert@acme.com", Name: "second"}, returning)
+	require.Nil(t, err)
+	require.Equal(t, upsertUser{ID: inserted.ID, Email: "upsert@acme.com", Name: "second"}, *updated)
+
+	_, err = goqux.Insert[upsertUser](ctx, conn, "upsert_users", upsertUser{Email: "upsert@acme.com", Name: "third"},
+		goqux.WithInsertOnConflictDoNothing("email"), returning)
+	require.ErrorIs(t, err, goqux.ErrNotFound)
+
+	users, err := goqux.UpsertMany[upsertUser](ctx, conn, "upsert_users", []any{
+		upsertUser{Email: "upsert@acme.com", Name: "fourth"},
+		upsertUser{Email: "upsert_many@acme.com", Name: "many"},
+	}, returning)
+	require.Nil(t, err)
+	require.Len(t, users, 2)
+	require.Equal(t, upsertUser{ID: inserted.ID, Email: "upsert@acme.com", Name: "fourth"}, users[0])
+
+	_, err = goqux.Delete[upsertUser](ctx, conn, "upsert_users")
+	require.Nil(t, err)
+}
+
+func TestCopyInsert(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	tx, err := conn.Begin(ctx)
+	require.Nil(t, err)
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	users := make([]pkUser, 1000)
+	for i := range users {
+		users[i] = pkUser{Username: fmt.Sprintf("copy_%d", i), Password: "copy", Email: "copy@acme.com"}
+	}
+	count, err := goqux.CopyInsert(ctx, tx, "users", users)
+	require.Nil(t, err)
+	require.Equal(t, int64(len(users)), count)
+
+	count, err = goqux.CopyInsertSeq(ctx, tx, "users", slices.Values(users[:10]))
+	require.Nil(t, err)
+	require.Equal(t, int64(10), count)
+
+	copied, err := goqux.Select[pkUser](ctx, tx, "users", goqux.WithSelectFilters(goqux.Column("users", "password").Eq("copy")))
+	require.Nil(t, err)
+	require.Len(t, copied, len(users)+10)
+}
+
 func TestInsert(t *testing.T) {
 	ctx := context.Background()
 	conn, err := pgx.Connect(ctx, testPostgresURI)
@@ -206,6 +617,116 @@ func TestInsertMany(t *testing.T) {
 	}
 }
 
+func TestInsertManyChunks(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	base := time.Now().Unix() + 1000
+	values := make([]any, 0, 10)
+	expected := make([]User, 0, 10)
+	for i := range int64(10) {
+		user := User{ID: base + i, Username: fmt.Sprintf("chunk%d", i), Password: "test", Email: "test"}
+		values = append(values, user)
+		expected = append(expected, user)
+	}
+	// four columns per row, so three rows per insert
+	models, err := goqux.InsertManyWithOptions[User](ctx, conn, "users", values, &goqux.InsertManyOptions{MaxParams: 12, Transaction: true}, goqux.WithInsertReturning("id", "username", "password", "email"))
+	require.Nil(t, err)
+	require.Equal(t, expected, models)
+
+	// a failing chunk rolls back the previous ones
+	values = []any{User{ID: base + 10, Username: "chunk10"}, User{ID: base + 10, Username: "chunk10"}}
+	_, err = goqux.InsertManyWithOptions[User](ctx, conn, "users", values, &goqux.InsertManyOptions{MaxParams: 4, Transaction: true})
+	require.ErrorIs(t, err, goqux.ErrUniqueViolation)
+	_, err = goqux.SelectOne[User](ctx, conn, "users", goqux.WithSelectFilters(goqux.Column("users", "id").Eq(base+10)))
+	require.ErrorIs(t, err, goqux.ErrNotFound)
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	id := time.Now().Unix() + 2000
+	returning := goqux.WithInsertReturning("id", "username", "password", "email")
+	b := goqux.NewBatch()
+	inserted := goqux.QueueInsert[User](b, "users", User{ID: id, Username: "batch", Password: "test", Email: "test"}, returning)
+	updated := goqux.QueueUpdate[User](b, "users", User{Username: "batch_updated"}, goqux.WithUpdateFilters(goqux.Column("users", "id").Eq(id)), goqux.WithUpdateReturning("id", "username", "password", "email"))
+	selected := goqux.QueueSelectOne[User](b, "users", goqux.WithSelectFilters(goqux.Column("users", "id").Eq(id)))
+	missing := goqux.QueueSelect[User](b, "users", goqux.WithSelectFilters(goqux.Column("users", "id").Eq(-1)))
+	deleted := goqux.QueueDelete[User](b, "users", goqux.WithDeleteFilters(goqux.Column("users", "id").Eq(id)))
+	require.Nil(t, b.Send(ctx, conn))
+
+	insertedUser, err := inserted.Result()
+	require.Nil(t, err)
+	require.Equal(t, &User{ID: id, Username: "batch", Password: "test", Email: "test"}, insertedUser)
+	updatedUsers, err := updated.Result()
+	require.Nil(t, err)
+	require.Equal(t, []User{{ID: id, Username: "batch_updated", Password: "test", Email: "test"}}, updatedUsers)
+	selectedUser, err := selected.Result()
+	require.Nil(t, err)
+	require.Equal(t, "batch_updated", selectedUser.Username)
+	missingUsers, err := missing.Result()
+	require.Nil(t, err)
+	require.Empty(t, missingUsers)
+	// without a returning option the deleted rows aren't returned
+	deletedUsers, err := deleted.Result()
+	require.Nil(t, err)
+	require.Empty(t, deletedUsers)
+
+	// a failing query fails the rest of the batch and rolls back the queries before it
+	b = goqux.NewBatch()
+	goqux.QueueInsert[User](b, "users", User{ID: id, Username: "batch", Password: "test", Email: "test"})
+	duplicate := goqux.QueueInsert[User](b, "users", User{ID: id, Username: "batch", Password: "test", Email: "test"})
+	after := goqux.QueueSelect[User](b, "users", goqux.WithSelectFilters(goqux.Column("users", "id").Eq(id)))
+	require.ErrorIs(t, b.Send(ctx, conn), goqux.ErrUniqueViolation)
+	_, err = duplicate.Result()
+	require.ErrorIs(t, err, goqux.ErrUniqueViolation)
+	_, err = after.Result()
+	require.Error(t, err)
+	_, err = goqux.SelectOne[User](ctx, conn, "users", goqux.WithSelectFilters(goqux.Column("users", "id").Eq(id)))
+	require.ErrorIs(t, err, goqux.ErrNotFound)
+}
+
+func TestUpdateMany(t *testing.T) {
+	type updateManyUser struct {
+		ID       int64 `db:"id" goqux:"pk"`
+		Username string
+		Password string `goqux:"skip_update"`
+		Email    string
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	id := time.Now().Unix() + 3000
+	users := []any{
+		User{ID: id, Username: "update_many", Password: "test", Email: "test"},
+		User{ID: id + 1, Username: "update_many", Password: "test", Email: "test"},
+	}
+	_, err = goqux.InsertMany[User](ctx, conn, "users", users)
+	require.Nil(t, err)
+	updated, err := goqux.UpdateMany(ctx, conn, "users", []updateManyUser{
+		{ID: id, Username: "first", Password: "ignored", Email: "first@goqux.com"},
+		{ID: id + 1, Username: "second"},
+	}, goqux.WithUpdateReturning("id", "username", "password", "email"))
+	require.Nil(t, err)
+	require.ElementsMatch(t, []updateManyUser{
+		{ID: id, Username: "first", Password: "test", Email: "first@goqux.com"},
+		{ID: id + 1, Username: "second", Password: "test", Email: ""},
+	}, updated)
+}
+
 func TestSelectPaginationWithManyRows(t *testing.T) {
 	ctx := context.Background()
 	conn, err := pgx.Connect(ctx, testPostgresURI)
@@ -251,6 +772,533 @@ func TestSelectPaginationWithManyRows(t *testing.T) {
 	}
 }
 
+func TestSelectPaginationWithDuplicateKeySetValues(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	expected, err := goqux.Select[keySetEvent](ctx, conn, "keyset_events")
+	require.Nil(t, err)
+	require.NotEmpty(t, expected)
+	for _, pageSize := range []uint{1, 2, 4, 7} {
+		paginator, err := goqux.SelectPagination[keySetEvent](ctx, conn, "keyset_events", &goqux.PaginationOptions{
+			PageSize: pageSize,
+			KeySet:   []string{"CreatedAt", "ID"},
+		})
+		require.Nil(t, err)
+		seen := make(map[int64]bool)
+		var previous *keySetEvent
+		for paginator.HasMorePages() {
+			models, err := paginator.NextPage()
+			require.Nil(t, err)
+			for _, m := range models {
+				m := m
+				require.False(t, seen[m.ID], "row %d returned twice", m.ID)
+				seen[m.ID] = true
+				if previous != nil {
+					require.False(t, m.CreatedAt.Before(previous.CreatedAt))
+					if m.CreatedAt.Equal(previous.CreatedAt) {
+						require.Greater(t, m.ID, previous.ID)
+					}
+				}
+				previous = &m
+			}
+		}
+		require.Len(t, seen, len(expected))
+	}
+}
+
+func TestSelectPaginationWithKeySetColumns(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	tableTests := []struct {
+		name   string
+		keyset []goqux.KeySetColumn
+		order  []exp.OrderedExpression
+	}{
+		{
+			name:   "newest_first",
+			keyset: []goqux.KeySetColumn{goqux.KeySetDesc("CreatedAt"), goqux.KeySetDesc("ID")},
+			order:  []exp.OrderedExpression{goqu.C("created_at").Desc(), goqu.C("id").Desc()},
+		},
+		{
+			name:   "mixed_directions",
+			keyset: []goqux.KeySetColumn{goqux.KeySetDesc("CreatedAt"), goqux.KeySetAsc("ID")},
+			order:  []exp.OrderedExpression{goqu.C("created_at").Desc(), goqu.C("id").Asc()},
+		},
+		{
+			name:   "nullable_nulls_last",
+			keyset: []goqux.KeySetColumn{goqux.KeySetAsc("Priority").NullsLast(), goqux.KeySetDesc("ID")},
+			order:  []exp.OrderedExpression{goqu.C("priority").Asc().NullsLast(), goqu.C("id").Desc()},
+		},
+		{
+			name:   "nullable_nulls_first",
+			keyset: []goqux.KeySetColumn{goqux.KeySetDesc("Priority").NullsFirst(), goqux.KeySetAsc("ID")},
+			order:  []exp.OrderedExpression{goqu.C("priority").Desc().NullsFirst(), goqu.C("id").Asc()},
+		},
+		{
+			name:   "nullable_desc_nulls_last",
+			keyset: []goqux.KeySetColumn{goqux.KeySetDesc("Priority").NullsLast(), goqux.KeySetDesc("ID")},
+			order:  []exp.OrderedExpression{goqu.C("priority").Desc().NullsLast(), goqu.C("id").Desc()},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := goqux.Select[keySetEvent](ctx, conn, "keyset_events", goqux.WithSelectOrder(tt.order...))
+			require.Nil(t, err)
+			paginator, err := goqux.SelectPagination[keySetEvent](ctx, conn, "keyset_events", &goqux.PaginationOptions{
+				PageSize:      3,
+				KeySetColumns: tt.keyset,
+			})
+			require.Nil(t, err)
+			allModels := make([]keySetEvent, 0)
+			for paginator.HasMorePages() {
+				models, err := paginator.NextPage()
+				require.Nil(t, err)
+				allModels = append(allModels, models...)
+			}
+			require.Equal(t, expected, allModels)
+
+			queryPaginator, err := goqux.QueryKeySetPaginationWithOptions[keySetEvent](ctx, conn, goqu.Dialect("postgres").From("keyset_events"), &goqux.PaginationOptions{
+				PageSize:      4,
+				KeySetColumns: tt.keyset,
+			})
+			require.Nil(t, err)
+			allModels = make([]keySetEvent, 0)
+			for queryPaginator.HasMorePages() {
+				models, err := queryPaginator.NextPage()
+				require.Nil(t, err)
+				allModels = append(allModels, models...)
+			}
+			require.Equal(t, expected, allModels)
+		})
+	}
+}
+
+func TestSelectPaginationFromCursor(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	tableTests := []struct {
+		name              string
+		paginationOptions *goqux.PaginationOptions
+		options           []goqux.SelectOption
+	}{
+		{
+			name: "keyset_cursor",
+			paginationOptions: &goqux.PaginationOptions{
+				PageSize:      4,
+				KeySetColumns: []goqux.KeySetColumn{goqux.KeySetDesc("CreatedAt"), goqux.KeySetAsc("Priority").NullsLast(), goqux.KeySetDesc("ID")},
+			},
+		},
+		{
+			name: "signed_keyset_cursor",
+			paginationOptions: &goqux.PaginationOptions{
+				PageSize:  4,
+				KeySet:    []string{"CreatedAt", "ID"},
+				CursorKey: []byte("secret"),
+			},
+		},
+		{
+			name: "offset_cursor",
+			paginationOptions: &goqux.PaginationOptions{
+				PageSize: 4,
+			},
+			options: []goqux.SelectOption{goqux.WithSelectOrder(goqu.C("id").Asc())},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			paginator, err := goqux.SelectPagination[keySetEvent](ctx, conn, "keyset_events", tt.paginationOptions, tt.options...)
+			require.Nil(t, err)
+			_, err = paginator.NextPage()
+			require.Nil(t, err)
+			cursor, err := paginator.Cursor()
+			require.Nil(t, err)
+			require.NotEmpty(t, cursor)
+			expected, err := paginator.NextPage()
+			require.Nil(t, err)
+
+			resumed, err := goqux.SelectPaginationFromCursor[keySetEvent](ctx, conn, "keyset_events", cursor, tt.paginationOptions, tt.options...)
+			require.Nil(t, err)
+			models, err := resumed.NextPage()
+			require.Nil(t, err)
+			require.Equal(t, expected, models)
+		})
+	}
+}
+
+func TestPaginateQueryByKeySetFromCursor(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	paginationOptions := &goqux.PaginationOptions{PageSize: 3, KeySet: []string{"CreatedAt", "ID"}, CursorKey: []byte("secret")}
+	sd := goqu.Dialect("postgres").From("keyset_events")
+	paginator, err := goqux.QueryKeySetPaginationWithOptions[keySetEvent](ctx, conn, sd, paginationOptions)
+	require.Nil(t, err)
+	_, err = paginator.NextPage()
+	require.Nil(t, err)
+	cursor, err := paginator.Cursor()
+	require.Nil(t, err)
+	expected, err := paginator.NextPage()
+	require.Nil(t, err)
+
+	resumed, err := goqux.QueryKeySetPaginationFromCursor[keySetEvent](ctx, conn, sd, cursor, paginationOptions)
+	require.Nil(t, err)
+	models, err := resumed.NextPage()
+	require.Nil(t, err)
+	require.Equal(t, expected, models)
+
+	_, err = goqux.QueryKeySetPaginationFromCursor[keySetEvent](ctx, conn, sd, cursor+"x", paginationOptions)
+	require.ErrorIs(t, err, goqux.ErrInvalidCursor)
+}
+
+func TestSelectPaginationPreviousPage(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	tableTests := []struct {
+		name              string
+		paginationOptions *goqux.PaginationOptions
+		options           []goqux.SelectOption
+	}{
+		{
+			name: "keyset",
+			paginationOptions: &goqux.PaginationOptions{
+				PageSize:      4,
+				KeySetColumns: []goqux.KeySetColumn{goqux.KeySetDesc("CreatedAt"), goqux.KeySetAsc("Priority").NullsLast(), goqux.KeySetAsc("ID")},
+			},
+		},
+		{
+			name: "offset",
+			paginationOptions: &goqux.PaginationOptions{
+				PageSize: 4,
+			},
+			options: []goqux.SelectOption{goqux.WithSelectOrder(goqu.C("id").Asc())},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			paginator, err := goqux.SelectPagination[keySetEvent](ctx, conn, "keyset_events", tt.paginationOptions, tt.options...)
+			require.Nil(t, err)
+			pages := make([][]keySetEvent, 0)
+			require.False(t, paginator.HasPreviousPages())
+			for i := 0; i < 3; i++ {
+				models, err := paginator.NextPage()
+				require.Nil(t, err)
+				pages = append(pages, models)
+			}
+			require.True(t, paginator.HasPreviousPages())
+			previous, err := paginator.PreviousPage()
+			require.Nil(t, err)
+			require.Equal(t, pages[1], previous)
+			require.True(t, paginator.HasPreviousPages())
+			previous, err = paginator.PreviousPage()
+			require.Nil(t, err)
+			require.Equal(t, pages[0], previous)
+			require.False(t, paginator.HasPreviousPages())
+			require.True(t, paginator.HasMorePages())
+			next, err := paginator.NextPage()
+			require.Nil(t, err)
+			require.Equal(t, pages[1], next)
+
+			cursor, err := paginator.PreviousCursor()
+			require.Nil(t, err)
+			resumed, err := goqux.SelectPaginationFromCursor[keySetEvent](ctx, conn, "keyset_events", cursor, tt.paginationOptions, tt.options...)
+			require.Nil(t, err)
+			previous, err = resumed.PreviousPage()
+			require.Nil(t, err)
+			require.Equal(t, pages[0], previous)
+		})
+	}
+}
+
+func TestPaginateQueryByKeySetPreviousPage(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	paginator, err := goqux.QueryKeySetPagination[keySetEvent](ctx, conn, goqu.Dialect("postgres").From("keyset_events"), 5, []string{"CreatedAt", "ID"})
+	require.Nil(t, err)
+	first, err := paginator.NextPage()
+	require.Nil(t, err)
+	_, err = paginator.NextPage()
+	require.Nil(t, err)
+	previous, err := paginator.PreviousPage()
+	require.Nil(t, err)
+	require.Equal(t, first, previous)
+	require.False(t, paginator.HasPreviousPages())
+}
+
+func TestSelectPaginationWithTotalCount(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	filter := goqux.WithSelectFilters(goqux.Column("keyset_events", "id").Lte(22))
+	paginator, err := goqux.SelectPagination[keySetEvent](ctx, conn, "keyset_events", &goqux.PaginationOptions{
+		PageSize:   5,
+		KeySet:     []string{"ID"},
+		CountTotal: true,
+	}, filter)
+	require.Nil(t, err)
+	require.Equal(t, uint(0), paginator.CurrentPage())
+	page := uint(0)
+	for paginator.HasMorePages() {
+		_, err := paginator.NextPage()
+		require.Nil(t, err)
+		page++
+		require.Equal(t, uint64(22), paginator.TotalCount())
+		require.Equal(t, uint(5), paginator.TotalPages())
+		require.Equal(t, page, paginator.CurrentPage())
+	}
+	_, err = paginator.PreviousPage()
+	require.Nil(t, err)
+	require.Equal(t, uint(4), paginator.CurrentPage())
+
+	queryPaginator, err := goqux.QueryKeySetPaginationWithOptions[keySetEvent](ctx, conn, goqu.Dialect("postgres").From("keyset_events").Where(goqu.C("id").Lte(22)), &goqux.PaginationOptions{
+		PageSize:   10,
+		KeySet:     []string{"ID"},
+		CountTotal: true,
+	})
+	require.Nil(t, err)
+	_, err = queryPaginator.NextPage()
+	require.Nil(t, err)
+	require.Equal(t, uint64(22), queryPaginator.TotalCount())
+	require.Equal(t, uint(3), queryPaginator.TotalPages())
+}
+
+func TestSelectServerCursorPagination(t *testing.T) {
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer pool.Close()
+	filter := goqux.WithSelectFilters(goqux.Column("keyset_events", "id").Lte(22))
+	order := goqux.WithSelectOrder(goqu.C("id").Asc())
+	expected, err := goqux.Select[keySetEvent](ctx, pool, "keyset_events", filter, order)
+	require.Nil(t, err)
+
+	paginator, err := goqux.SelectServerCursorPagination[keySetEvent](ctx, pool, "keyset_events", &goqux.PaginationOptions{PageSize: 5, CountTotal: true}, filter, order)
+	require.Nil(t, err)
+	allModels := make([]keySetEvent, 0)
+	pages := 0
+	for paginator.HasMorePages() {
+		models, err := paginator.NextPage()
+		require.Nil(t, err)
+		allModels = append(allModels, models...)
+		pages++
+	}
+	require.Equal(t, expected, allModels)
+	require.Equal(t, 5, pages)
+	require.Equal(t, uint64(22), paginator.TotalCount())
+	// the transaction is closed, so its connection is released
+	require.Equal(t, int32(0), pool.Stat().AcquiredConns())
+	require.Nil(t, paginator.Close())
+
+	// inside a transaction the cursor is declared in a savepoint, and the transaction can be used once it's closed
+	tx, err := pool.Begin(ctx)
+	require.Nil(t, err)
+	defer func() {
+		require.Nil(t, tx.Rollback(ctx))
+	}()
+	paginator, err = goqux.SelectServerCursorPagination[keySetEvent](ctx, tx, "keyset_events", &goqux.PaginationOptions{PageSize: 5}, filter, order)
+	require.Nil(t, err)
+	allModels = allModels[:0]
+	for paginator.HasMorePages() {
+		models, err := paginator.NextPage()
+		require.Nil(t, err)
+		allModels = append(allModels, models...)
+	}
+	require.Equal(t, expected, allModels)
+	_, err = goqux.Select[keySetEvent](ctx, tx, "keyset_events", filter)
+	require.Nil(t, err)
+}
+
+func TestSelectServerCursorPaginationClose(t *testing.T) {
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer pool.Close()
+	paginator, err := goqux.SelectServerCursorPagination[keySetEvent](ctx, pool, "keyset_events", &goqux.PaginationOptions{PageSize: 5})
+	require.Nil(t, err)
+	_, err = paginator.NextPage()
+	require.Nil(t, err)
+	require.Nil(t, paginator.Close())
+	require.Nil(t, paginator.Close())
+	_, err = paginator.NextPage()
+	require.Error(t, err)
+	require.Equal(t, int32(0), pool.Stat().AcquiredConns())
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	paginator, err = goqux.SelectServerCursorPagination[keySetEvent](cancelCtx, pool, "keyset_events", &goqux.PaginationOptions{PageSize: 5})
+	require.Nil(t, err)
+	_, err = paginator.NextPage()
+	require.Nil(t, err)
+	cancel()
+	_, err = paginator.NextPage()
+	require.ErrorIs(t, err, context.Canceled)
+	require.Eventually(t, func() bool {
+		return pool.Stat().AcquiredConns() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSelectPaginationWithPrefetch(t *testing.T) {
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer pool.Close()
+	expected, err := goqux.Select[keySetEvent](ctx, pool, "keyset_events", goqux.WithSelectOrder(goqu.C("id").Asc()))
+	require.Nil(t, err)
+	paginator, err := goqux.SelectPagination[keySetEvent](ctx, pool, "keyset_events", &goqux.PaginationOptions{
+		PageSize: 4,
+		KeySet:   []string{"ID"},
+		Prefetch: 3,
+	})
+	require.Nil(t, err)
+	allModels := make([]keySetEvent, 0)
+	for paginator.HasMorePages() {
+		models, err := paginator.NextPage()
+		require.Nil(t, err)
+		allModels = append(allModels, models...)
+		// the querier can be used while pages are prefetched
+		_, err = goqux.SelectOne[keySetEvent](ctx, pool, "keyset_events")
+		require.Nil(t, err)
+	}
+	require.Equal(t, expected, allModels)
+	require.Nil(t, paginator.Close())
+}
+
+func TestParallelScan(t *testing.T) {
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer pool.Close()
+	tableTests := []struct {
+		name        string
+		scanOptions *goqux.ParallelScanOptions
+		options     []goqux.SelectOption
+	}{
+		{
+			name:        "integer_key",
+			scanOptions: &goqux.ParallelScanOptions{Key: "ID", Partitions: 4, PageSize: 5},
+		},
+		{
+			name:        "timestamp_key",
+			scanOptions: &goqux.ParallelScanOptions{Key: "CreatedAt", KeySet: []string{"CreatedAt", "ID"}, Partitions: 3, PageSize: 4},
+		},
+		{
+			name:        "timestamp_key_default_keyset",
+			scanOptions: &goqux.ParallelScanOptions{Key: "CreatedAt", Partitions: 3, PageSize: 4},
+		},
+		{
+			name:        "with_filters",
+			scanOptions: &goqux.ParallelScanOptions{Key: "ID", Partitions: 8, PageSize: 2},
+			options:     []goqux.SelectOption{goqux.WithSelectFilters(goqux.Column("keyset_events", "id").Gt(40))},
+		},
+		{
+			name:        "no_rows",
+			scanOptions: &goqux.ParallelScanOptions{Key: "ID"},
+			options:     []goqux.SelectOption{goqux.WithSelectFilters(goqux.Column("keyset_events", "id").Lt(0))},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := goqux.Select[keySetEvent](ctx, pool, "keyset_events", append(tt.options, goqux.WithSelectOrder(goqu.C("id").Asc()))...)
+			require.Nil(t, err)
+			var mu sync.Mutex
+			scanned := make([]keySetEvent, 0)
+			done := make(map[int]uint64)
+			tt.scanOptions.Progress = func(progress goqux.ScanProgress) {
+				mu.Lock()
+				defer mu.Unlock()
+				if progress.Done {
+					done[progress.Partition] = progress.Rows
+				}
+			}
+			err = goqux.ParallelScan(ctx, pool, "keyset_events", tt.scanOptions, func(event keySetEvent) error {
+				mu.Lock()
+				defer mu.Unlock()
+				scanned = append(scanned, event)
+				return nil
+			}, tt.options...)
+			require.Nil(t, err)
+			sort.Slice(scanned, func(i, j int) bool { return scanned[i].ID < scanned[j].ID })
+			require.Equal(t, expected, scanned)
+			total := uint64(0)
+			for _, rows := range done {
+				total += rows
+			}
+			require.Equal(t, uint64(len(expected)), total)
+		})
+	}
+}
+
+func TestParallelScanError(t *testing.T) {
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer pool.Close()
+	failed := errors.New("failed")
+	err = goqux.ParallelScan(ctx, pool, "keyset_events", &goqux.ParallelScanOptions{Key: "ID", PageSize: 2}, func(event keySetEvent) error {
+		return failed
+	})
+	require.ErrorIs(t, err, failed)
+	err = goqux.ParallelScan(ctx, pool, "keyset_events", &goqux.ParallelScanOptions{Key: "ID", PageSize: 2}, func(event keySetEvent) error {
+		return goqux.ErrStopIteration
+	})
+	require.Nil(t, err)
+}
+
+func TestPaginateQueryByKeySetWithDuplicateKeySetValues(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, testPostgresURI)
+	require.Nil(t, err)
+	defer func() {
+		err := conn.Close(context.Background())
+		require.Nil(t, err)
+	}()
+	expected, err := goqux.Select[keySetEvent](ctx, conn, "keyset_events")
+	require.Nil(t, err)
+	paginator, err := goqux.QueryKeySetPagination[keySetEvent](ctx, conn, goqu.Dialect("postgres").Select("id", "created_at").From("keyset_events"), 2, []string{"CreatedAt", "ID"})
+	require.Nil(t, err)
+	seen := make(map[int64]bool)
+	for paginator.HasMorePages() {
+		models, err := paginator.NextPage()
+		require.Nil(t, err)
+		for _, m := range models {
+			require.False(t, seen[m.ID], "row %d returned twice", m.ID)
+			seen[m.ID] = true
+		}
+	}
+	require.Len(t, seen, len(expected))
+}
+
 func TestSelectPagination(t *testing.T) {
 	ctx := context.Background()
 	conn, err := pgx.Connect(ctx, testPostgresURI)
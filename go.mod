@@ -1,6 +1,6 @@
 module github.com/roneli/goqux
 
-go 1.19
+go 1.23
 
 require (
 	github.com/doug-martin/goqu/v9 v9.18.0
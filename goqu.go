@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/doug-martin/goqu/v9/exp"
@@ -20,9 +21,18 @@ func init() {
 	goqu.SetDefaultPrepared(true)
 }
 
-// Column is shorthand for goqu.T(table).Col(column).
+// Column is shorthand for goqu.T(table).Col(column), a schema qualified table such as billing.invoices is split into its schema and table.
 func Column(table string, column string) exp.IdentifierExpression {
-	return goqu.T(table).Col(column)
+	return tableIdentifier(table).Col(column)
+}
+
+// tableIdentifier returns the identifier of the table, a schema qualified name such as billing.invoices is split into its
+// schema and table.
+func tableIdentifier(tableName string) exp.IdentifierExpression {
+	if schema, table, ok := strings.Cut(tableName, "."); ok {
+		return goqu.S(schema).Table(table)
+	}
+	return goqu.T(tableName)
 }
 
 // SetDefaultDialect sets the default dialect for goqux.
@@ -1,6 +1,9 @@
 package goqux
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/doug-martin/goqu/v9"
 	"github.com/doug-martin/goqu/v9/exp"
 )
@@ -36,14 +39,65 @@ func WithInsertNotPrepared() InsertOption {
 }
 
 func BuildInsert(tableName string, values []any, options ...InsertOption) (string, []any, error) {
-	table := goqu.T(tableName)
+	q, err := buildInsertDataset(tableName, values, false, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	return q.ToSQL()
+}
+
+// insertQuery is an insert dataset with the conflict target of ON CONFLICT DO NOTHING, which goqu can't render.
+type insertQuery struct {
+	*goqu.InsertDataset
+	doNothingTarget string
+}
+
+func (q insertQuery) ToSQL() (string, []any, error) {
+	query, args, err := q.InsertDataset.ToSQL()
+	if err != nil || q.doNothingTarget == "" {
+		return query, args, err
+	}
+	// the conflict clause ends the query without its returning clause, which has no args, so the target is added
+	// right before the returning clause rather than searched for in values that may contain the same text
+	withoutReturning, _, err := q.InsertDataset.Returning().ToSQL()
+	if err != nil {
+		return "", nil, err
+	}
+	const doNothing = " ON CONFLICT DO NOTHING"
+	if !strings.HasSuffix(withoutReturning, doNothing) || !strings.HasPrefix(query, withoutReturning) {
+		return "", nil, fmt.Errorf("goqux: failed to add the conflict target to %q", query)
+	}
+	return strings.TrimSuffix(withoutReturning, doNothing) + " ON CONFLICT " + q.doNothingTarget + " DO NOTHING" +
+		query[len(withoutReturning):], args, nil
+}
+
+// buildInsertDataset builds the insert dataset, resolving the conflict clause of the upsert options from the values. With
+// redact the goqux:"sensitive" values are replaced for a QueryError.
+func buildInsertDataset(tableName string, values []any, redact bool, options ...InsertOption) (insertQuery, error) {
+	table := tableIdentifier(tableName)
 	q := goqu.Insert(table).WithDialect(defaultDialect)
 	encodedValues := make([]map[string]SQLValuer, len(values))
 	for i, value := range values {
-		encodedValues[i] = encodeValues(value, skipInsert, false)
+		encoded, err := encodeValues(value, skipInsert, false)
+		if err != nil {
+			return insertQuery{}, err
+		}
+		if redact {
+			redactSensitiveValues(value, encoded)
+		}
+		encodedValues[i] = encoded
 	}
 	for _, o := range options {
 		q = o(table, q)
 	}
-	return q.Rows(encodedValues).ToSQL()
+	var doNothingTarget string
+	if c, ok := q.GetClauses().OnConflict().(*upsertConflict); ok {
+		conflict, target, err := c.resolve(values, encodedValues)
+		if err != nil {
+			return insertQuery{}, err
+		}
+		q = q.OnConflict(conflict)
+		doNothingTarget = target
+	}
+	return insertQuery{InsertDataset: q.Rows(encodedValues), doNothingTarget: doNothingTarget}, nil
 }
@@ -0,0 +1,106 @@
+package goqux
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertManyWithOptionsChunking(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+	values := []any{
+		querierModel{ID: 1, Name: "a"},
+		querierModel{ID: 2, Name: "b"},
+		querierModel{ID: 3, Name: "c"},
+	}
+	tableTests := []struct {
+		name              string
+		opts              *InsertManyOptions
+		queryErrs         []error
+		expectedQueries   []string
+		expectedResults   int
+		expectedErr       error
+		expectedCommits   int
+		expectedRollbacks int
+	}{
+		{
+			name:            "single_chunk",
+			expectedQueries: []string{`INSERT INTO "models" ("id", "name") VALUES ($1, $2), ($3, $4), ($5, $6) RETURNING "models".*`},
+			expectedResults: 1,
+		},
+		{
+			name: "chunks",
+			opts: &InsertManyOptions{MaxParams: 5},
+			expectedQueries: []string{
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2), ($3, $4) RETURNING "models".*`,
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2) RETURNING "models".*`,
+			},
+			expectedResults: 2,
+		},
+		{
+			name: "row_per_chunk_below_column_count",
+			opts: &InsertManyOptions{MaxParams: 1},
+			expectedQueries: []string{
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2) RETURNING "models".*`,
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2) RETURNING "models".*`,
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2) RETURNING "models".*`,
+			},
+			expectedResults: 3,
+		},
+		{
+			name: "transaction",
+			opts: &InsertManyOptions{MaxParams: 4, Transaction: true},
+			expectedQueries: []string{
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2), ($3, $4) RETURNING "models".*`,
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2) RETURNING "models".*`,
+			},
+			expectedResults: 2,
+			expectedCommits: 1,
+		},
+		{
+			name:      "transaction_rollback",
+			opts:      &InsertManyOptions{MaxParams: 4, Transaction: true},
+			queryErrs: []error{nil, errFailed},
+			expectedQueries: []string{
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2), ($3, $4) RETURNING "models".*`,
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2) RETURNING "models".*`,
+			},
+			expectedErr:       errFailed,
+			expectedRollbacks: 1,
+		},
+		{
+			name:      "error_without_transaction",
+			opts:      &InsertManyOptions{MaxParams: 4},
+			queryErrs: []error{nil, errFailed},
+			expectedQueries: []string{
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2), ($3, $4) RETURNING "models".*`,
+				`INSERT INTO "models" ("id", "name") VALUES ($1, $2) RETURNING "models".*`,
+			},
+			expectedErr: errFailed,
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &fakeDriver{
+				columns:   []string{"id", "name"},
+				rows:      [][]driver.Value{{int64(1), "a"}},
+				queryErrs: tt.queryErrs,
+			}
+			results, err := InsertManyWithOptions[querierModel](ctx, newFakeSQLQuerier(t, d), "models", values, tt.opts, WithInsertReturning("*"))
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, results, tt.expectedResults)
+			}
+			assert.Equal(t, tt.expectedQueries, d.queries)
+			assert.Equal(t, tt.expectedCommits, d.commits)
+			assert.Equal(t, tt.expectedRollbacks, d.rollbacks)
+		})
+	}
+}
@@ -79,3 +79,125 @@ func TestBuildInsert(t *testing.T) {
 		})
 	}
 }
+
+type upsertModel struct {
+	ID        int64  `goqux:"pk,skip_update"`
+	Email     string `goqux:"unique"`
+	Name      string
+	CreatedAt string `goqux:"skip_update"`
+}
+
+type upsertPKModel struct {
+	TenantID int64 `goqux:"pk"`
+	ID       int64 `goqux:"pk"`
+	Name     string
+}
+
+func TestBuildInsertOnConflict(t *testing.T) {
+	testTables := []struct {
+		name          string
+		values        []any
+		opts          []goqux.InsertOption
+		expectedQuery string
+		expectedArgs  []interface{}
+		expectedError error
+	}{
+		{
+			name:          "do_nothing",
+			values:        []any{upsertPKModel{TenantID: 1, ID: 2, Name: "a"}},
+			opts:          []goqux.InsertOption{goqux.WithInsertOnConflictDoNothing()},
+			expectedQuery: `INSERT INTO "upsert_models" ("id", "name", "tenant_id") VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+			expectedArgs:  []interface{}{int64(2), "a", int64(1)},
+		},
+		{
+			name:          "do_nothing_with_columns_and_returning",
+			values:        []any{upsertPKModel{TenantID: 1, ID: 2, Name: "a"}},
+			opts:          []goqux.InsertOption{goqux.WithInsertOnConflictDoNothing("tenant_id", "id"), goqux.WithInsertReturning("id")},
+			expectedQuery: `INSERT INTO "upsert_models" ("id", "name", "tenant_id") VALUES ($1, $2, $3) ON CONFLICT ("tenant_id", "id") DO NOTHING RETURNING "upsert_models"."id"`,
+			expectedArgs:  []interface{}{int64(2), "a", int64(1)},
+		},
+		{
+			name:   "do_nothing_with_columns_not_prepared",
+			values: []any{upsertPKModel{TenantID: 1, ID: 2, Name: "a ON CONFLICT DO NOTHING"}},
+			opts: []goqux.InsertOption{goqux.WithInsertOnConflictDoNothing("tenant_id", "id"), goqux.WithInsertNotPrepared(),
+				goqux.WithInsertReturningAll()},
+			expectedQuery: `INSERT INTO "upsert_models" ("id", "name", "tenant_id") VALUES (2, 'a ON CONFLICT DO NOTHING', 1) ON CONFLICT ("tenant_id", "id") DO NOTHING RETURNING *`,
+			expectedArgs:  []interface{}{},
+		},
+		{
+			name:          "do_nothing_with_columns_and_returning_conflict_text",
+			values:        []any{upsertPKModel{TenantID: 1, ID: 2, Name: "a"}},
+			opts:          []goqux.InsertOption{goqux.WithInsertOnConflictDoNothing("id"), goqux.WithInsertReturning("id", "x ON CONFLICT DO NOTHING")},
+			expectedQuery: `INSERT INTO "upsert_models" ("id", "name", "tenant_id") VALUES ($1, $2, $3) ON CONFLICT ("id") DO NOTHING RETURNING "upsert_models"."id", "upsert_models"."x ON CONFLICT DO NOTHING"`,
+			expectedArgs:  []interface{}{int64(2), "a", int64(1)},
+		},
+		{
+			name:          "do_nothing_on_constraint",
+			values:        []any{upsertPKModel{TenantID: 1, ID: 2, Name: "a"}},
+			opts:          []goqux.InsertOption{goqux.WithInsertOnConflict(goqux.OnConflict{Constraint: "upsert_models_pkey", DoNothing: true})},
+			expectedQuery: `INSERT INTO "upsert_models" ("id", "name", "tenant_id") VALUES ($1, $2, $3) ON CONFLICT ON CONSTRAINT "upsert_models_pkey" DO NOTHING`,
+			expectedArgs:  []interface{}{int64(2), "a", int64(1)},
+		},
+		{
+			name:          "do_update_unique_target",
+			values:        []any{upsertModel{ID: 1, Email: "a@acme.com", Name: "a", CreatedAt: "now"}},
+			opts:          []goqux.InsertOption{goqux.WithInsertOnConflictDoUpdate()},
+			expectedQuery: `INSERT INTO "upsert_models" ("created_at", "email", "id", "name") VALUES ($1, $2, $3, $4) ON CONFLICT ("email") DO UPDATE SET "name"="excluded"."name"`,
+			expectedArgs:  []interface{}{"now", "a@acme.com", int64(1), "a"},
+		},
+		{
+			name:          "do_update_pk_target",
+			values:        []any{upsertPKModel{TenantID: 1, ID: 2, Name: "a"}, upsertPKModel{TenantID: 1, ID: 3, Name: "b"}},
+			opts:          []goqux.InsertOption{goqux.WithInsertOnConflictDoUpdate()},
+			expectedQuery: `INSERT INTO "upsert_models" ("id", "name", "tenant_id") VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT ("tenant_id", "id") DO UPDATE SET "name"="excluded"."name"`,
+			expectedArgs:  []interface{}{int64(2), "a", int64(1), int64(3), "b", int64(1)},
+		},
+		{
+			name:          "do_update_columns",
+			values:        []any{upsertModel{ID: 1, Email: "a@acme.com", Name: "a"}},
+			opts:          []goqux.InsertOption{goqux.WithInsertOnConflictDoUpdate("id")},
+			expectedQuery: `INSERT INTO "upsert_models" ("created_at", "email", "id", "name") VALUES ($1, $2, $3, $4) ON CONFLICT ("id") DO UPDATE SET "email"="excluded"."email","name"="excluded"."name"`,
+			expectedArgs:  []interface{}{"", "a@acme.com", int64(1), "a"},
+		},
+		{
+			name:   "do_update_constraint_and_update_columns",
+			values: []any{upsertModel{ID: 1, Email: "a@acme.com", Name: "a"}},
+			opts: []goqux.InsertOption{goqux.WithInsertOnConflict(goqux.OnConflict{Constraint: "upsert_models_email_key",
+				Update: []string{"name", "created_at"}})},
+			expectedQuery: `INSERT INTO "upsert_models" ("created_at", "email", "id", "name") VALUES ($1, $2, $3, $4) ON CONFLICT ON CONSTRAINT "upsert_models_email_key" DO UPDATE SET "created_at"="excluded"."created_at","name"="excluded"."name"`,
+			expectedArgs:  []interface{}{"", "a@acme.com", int64(1), "a"},
+		},
+		{
+			name:          "do_update_map",
+			values:        []any{map[string]any{"id": 1, "name": "a"}},
+			opts:          []goqux.InsertOption{goqux.WithInsertOnConflictDoUpdate("id")},
+			expectedQuery: `INSERT INTO "upsert_models" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name"="excluded"."name"`,
+			expectedArgs:  []interface{}{int64(1), "a"},
+		},
+		{
+			name:          "do_update_only_keys",
+			values:        []any{map[string]any{"id": 1}},
+			opts:          []goqux.InsertOption{goqux.WithInsertOnConflictDoUpdate("id"), goqux.WithInsertReturning("id")},
+			expectedQuery: `INSERT INTO "upsert_models" ("id") VALUES ($1) ON CONFLICT ("id") DO NOTHING RETURNING "upsert_models"."id"`,
+			expectedArgs:  []interface{}{int64(1)},
+		},
+		{
+			name:          "do_update_without_target",
+			values:        []any{insertModel{IntField: 5}},
+			opts:          []goqux.InsertOption{goqux.WithInsertOnConflictDoUpdate()},
+			expectedError: goqux.ErrMissingPrimaryKey,
+		},
+	}
+	for _, tt := range testTables {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := goqux.BuildInsert("upsert_models", tt.values, tt.opts...)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package goqux
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns an iterator over the rows of all remaining pages, iteration stops after the first error.
+//
+//	for user, err := range paginator.All() {
+//		...
+//	}
+func (p *Paginator[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page, err := range p.Pages() {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, row := range page {
+				if !yield(row, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Pages returns an iterator over all remaining pages, iteration stops after the first error.
+func (p *Paginator[T]) Pages() iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		for p.HasMorePages() {
+			page, err := p.NextPage()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(page) == 0 {
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+		}
+	}
+}
+
+// SelectSeq is like Select, but returns an iterator streaming the rows one by one instead of loading all of them into a slice,
+// the query runs when the iteration starts and the rows are closed when it ends. Iteration stops after the first error.
+func SelectSeq[T any](ctx context.Context, querier Querier, tableName string, options ...SelectOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := SelectEach(ctx, querier, tableName, func(row T) error {
+			if !yield(row, nil) {
+				return ErrStopIteration
+			}
+			return nil
+		}, options...)
+		if err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
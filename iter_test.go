@@ -0,0 +1,58 @@
+package goqux_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/roneli/goqux"
+)
+
+func newSlicePaginator(pages [][]int, err error) (*goqux.Paginator[int], *int) {
+	calls := 0
+	return goqux.NewPaginator(func(p *goqux.Paginator[int]) ([]int, bool, error) {
+		calls++
+		if calls > len(pages) {
+			return nil, false, err
+		}
+		return pages[calls-1], err == nil && calls == len(pages), nil
+	}), &calls
+}
+
+func TestPaginatorAll(t *testing.T) {
+	paginator, _ := newSlicePaginator([][]int{{1, 2}, {3}, {4, 5}}, nil)
+	rows := make([]int, 0)
+	for row, err := range paginator.All() {
+		assert.NoError(t, err)
+		rows = append(rows, row)
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, rows)
+}
+
+func TestPaginatorAllStopsEarly(t *testing.T) {
+	paginator, calls := newSlicePaginator([][]int{{1, 2}, {3}, {4, 5}}, nil)
+	for row, err := range paginator.All() {
+		assert.NoError(t, err)
+		if row == 3 {
+			break
+		}
+	}
+	assert.Equal(t, 2, *calls)
+}
+
+func TestPaginatorPagesWithError(t *testing.T) {
+	expectedErr := errors.New("failed")
+	paginator, _ := newSlicePaginator([][]int{{1, 2}}, expectedErr)
+	pages := make([][]int, 0)
+	var iterErr error
+	for page, err := range paginator.Pages() {
+		if err != nil {
+			iterErr = err
+			continue
+		}
+		pages = append(pages, page)
+	}
+	assert.ErrorIs(t, iterErr, expectedErr)
+	assert.Equal(t, [][]int{{1, 2}}, pages)
+}
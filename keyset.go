@@ -0,0 +1,227 @@
+package goqux
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// rowComparisonDialects are the dialects that support row value comparison i.e. (a, b) > (1, 2),
+// any other dialect will get the expanded OR-chain form of the same predicate.
+var rowComparisonDialects = map[string]bool{
+	"postgres": true,
+	"mysql":    true,
+	"sqlite3":  true,
+}
+
+// NullsOrder sets where NULL values of a keyset column are ordered.
+type NullsOrder int
+
+const (
+	// NullsDefault assumes the column is NOT NULL, no NULL handling is added to the query.
+	NullsDefault NullsOrder = iota
+	// NullsFirst orders NULL values before any other value.
+	NullsFirst
+	// NullsLast orders NULL values after any other value.
+	NullsLast
+)
+
+// KeySetColumn describes a single keyset pagination column.
+type KeySetColumn struct {
+	// Name of the struct field, the column name is the snake case of the name.
+	Name string
+	// Desc orders the column in descending order.
+	Desc bool
+	// Nulls sets where NULL values are ordered, set it for columns that can be NULL.
+	Nulls NullsOrder
+}
+
+// KeySetAsc returns an ascending keyset column for the given struct field.
+func KeySetAsc(name string) KeySetColumn {
+	return KeySetColumn{Name: name}
+}
+
+// KeySetDesc returns a descending keyset column for the given struct field.
+func KeySetDesc(name string) KeySetColumn {
+	return KeySetColumn{Name: name, Desc: true}
+}
+
+// NullsFirst returns a copy of the column ordering NULL values first.
+func (c KeySetColumn) NullsFirst() KeySetColumn {
+	c.Nulls = NullsFirst
+	return c
+}
+
+// NullsLast returns a copy of the column ordering NULL values last.
+func (c KeySetColumn) NullsLast() KeySetColumn {
+	c.Nulls = NullsLast
+	return c
+}
+
+func (c KeySetColumn) order(col exp.IdentifierExpression) exp.OrderedExpression {
+	o := col.Asc()
+	if c.Desc {
+		o = col.Desc()
+	}
+	switch c.Nulls {
+	case NullsFirst:
+		return o.NullsFirst()
+	case NullsLast:
+		return o.NullsLast()
+	default:
+		return o
+	}
+}
+
+// after returns an expression matching the values that are ordered strictly after v, or nil if there are none.
+func (c KeySetColumn) after(col exp.IdentifierExpression, v any) exp.Expression {
+	var cmp exp.Expression = col.Gt(v)
+	if c.Desc {
+		cmp = col.Lt(v)
+	}
+	switch {
+	case c.Nulls == NullsFirst && v == nil:
+		return col.IsNotNull()
+	case c.Nulls == NullsLast && v == nil:
+		return nil
+	case c.Nulls == NullsLast:
+		return goqu.Or(cmp, col.IsNull())
+	default:
+		return cmp
+	}
+}
+
+func (c KeySetColumn) equal(col exp.IdentifierExpression, v any) exp.Expression {
+	if v == nil && c.Nulls != NullsDefault {
+		return col.IsNull()
+	}
+	return col.Eq(v)
+}
+
+// reverseKeySet returns the keyset in the opposite order, used to fetch the rows before a keyset position.
+func reverseKeySet(keyset []KeySetColumn) []KeySetColumn {
+	reversed := make([]KeySetColumn, len(keyset))
+	for i, c := range keyset {
+		c.Desc = !c.Desc
+		switch c.Nulls {
+		case NullsFirst:
+			c.Nulls = NullsLast
+		case NullsLast:
+			c.Nulls = NullsFirst
+		}
+		reversed[i] = c
+	}
+	return reversed
+}
+
+func keySetFromNames(names []string) []KeySetColumn {
+	keyset := make([]KeySetColumn, len(names))
+	for i, n := range names {
+		keyset[i] = KeySetAsc(n)
+	}
+	return keyset
+}
+
+// keySetOrder returns the ORDER BY expressions of the keyset.
+func keySetOrder(keyset []KeySetColumn, columns []exp.IdentifierExpression) []exp.OrderedExpression {
+	order := make([]exp.OrderedExpression, len(keyset))
+	for i, c := range keyset {
+		order[i] = c.order(columns[i])
+	}
+	return order
+}
+
+// canCompareRows returns true if the keyset predicate can be expressed as a single row value comparison,
+// which requires all columns to share the same direction and to be NOT NULL.
+func canCompareRows(dialect string, keyset []KeySetColumn) bool {
+	if !rowComparisonDialects[dialect] {
+		return false
+	}
+	for _, c := range keyset {
+		if c.Nulls != NullsDefault || c.Desc != keyset[0].Desc {
+			return false
+		}
+	}
+	return true
+}
+
+// keySetPredicate returns an expression matching all rows that come strictly after values in the lexicographic
+// order of the keyset, for example (a, b) > ($1, $2) or (a > $1) OR (a = $1 AND b > $2) when row comparison isn't
+// supported or the keyset mixes directions and NULL ordering.
+func keySetPredicate(dialect string, keyset []KeySetColumn, columns []exp.IdentifierExpression, values []any) exp.Expression {
+	if len(keyset) > 1 && canCompareRows(dialect, keyset) {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+		op := ">"
+		if keyset[0].Desc {
+			op = "<"
+		}
+		args := make([]any, 0, len(columns)*2)
+		for _, c := range columns {
+			args = append(args, c)
+		}
+		args = append(args, values...)
+		return goqu.L("("+placeholders+") "+op+" ("+placeholders+")", args...)
+	}
+	ors := make([]exp.Expression, 0, len(columns))
+	for i, c := range keyset {
+		after := c.after(columns[i], values[i])
+		if after == nil {
+			continue
+		}
+		ands := make([]exp.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, keyset[j].equal(columns[j], values[j]))
+		}
+		if len(ands) == 0 {
+			ors = append(ors, after)
+			continue
+		}
+		ors = append(ors, goqu.And(append(ands, after)...))
+	}
+	switch len(ors) {
+	case 0:
+		// the last row holds the greatest possible keyset, there is nothing after it
+		return goqu.L("1 = 0")
+	case 1:
+		return ors[0]
+	default:
+		return goqu.Or(ors...)
+	}
+}
+
+// keySetValues returns the keyset values of the given row, NULL values are returned as nil.
+func keySetValues(row any, keyset []KeySetColumn) ([]any, error) {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	metadata, err := getStructMetadata(v.Type())
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(keyset))
+	for i, c := range keyset {
+		if f, ok := metadata.field(c.Name); ok {
+			values[i] = keySetValue(v.FieldByIndex(f.index))
+			continue
+		}
+		values[i] = keySetValue(v.FieldByName(c.Name))
+	}
+	return values, nil
+}
+
+func keySetValue(v reflect.Value) any {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	value := v.Interface()
+	if valuer, ok := value.(driver.Valuer); ok {
+		if dv, err := valuer.Value(); err == nil && dv == nil {
+			return nil
+		}
+	}
+	return value
+}
@@ -0,0 +1,99 @@
+package goqux
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySetValues(t *testing.T) {
+	value := int64(5)
+	tableTests := []struct {
+		name     string
+		row      any
+		keyset   []KeySetColumn
+		expected []any
+	}{
+		{
+			name: "plain_values",
+			row: struct {
+				ID   int64
+				Name string
+			}{ID: 1, Name: "test"},
+			keyset:   []KeySetColumn{KeySetAsc("Name"), KeySetAsc("ID")},
+			expected: []any{"test", int64(1)},
+		},
+		{
+			name: "pointer_values",
+			row: struct {
+				Priority *int64
+				Other    *int64
+			}{Priority: &value},
+			keyset:   []KeySetColumn{KeySetAsc("Priority"), KeySetAsc("Other")},
+			expected: []any{int64(5), nil},
+		},
+		{
+			name: "null_valuer",
+			row: struct {
+				Name sql.NullString
+			}{},
+			keyset:   []KeySetColumn{KeySetAsc("Name")},
+			expected: []any{nil},
+		},
+		{
+			name: "pointer_row",
+			row: &struct {
+				ID int64
+			}{ID: 3},
+			keyset:   []KeySetColumn{KeySetAsc("ID")},
+			expected: []any{int64(3)},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := keySetValues(tt.row, tt.keyset)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
+
+func TestKeySetValuesInvalidTag(t *testing.T) {
+	row := struct {
+		ID int64 `goqux:"skip_compare"`
+	}{ID: 1}
+	_, err := keySetValues(row, []KeySetColumn{KeySetAsc("ID")})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+}
+
+func TestQueryKeySetPaginationInvalidTag(t *testing.T) {
+	type invalidTagModel struct {
+		ID int64 `goqux:"skip_compare"`
+	}
+	d := &fakeDriver{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}
+	sd := goqu.From("models").Select("id")
+	_, err := QueryKeySetPagination[invalidTagModel](context.Background(), newFakeSQLQuerier(t, d), sd, 10, []string{"ID"})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	assert.Empty(t, d.queries)
+}
+
+func TestKeySetPredicateWithNothingAfter(t *testing.T) {
+	keyset := []KeySetColumn{KeySetAsc("Priority").NullsLast()}
+	cols := []exp.IdentifierExpression{goqu.C("priority")}
+	query, _, err := goqu.Dialect("postgres").From("t").Where(keySetPredicate("postgres", keyset, cols, []any{nil})).ToSQL()
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "t" WHERE 1 = 0`, query)
+}
+
+func TestReverseKeySet(t *testing.T) {
+	keyset := []KeySetColumn{KeySetAsc("CreatedAt"), KeySetDesc("Priority").NullsFirst(), KeySetAsc("ID").NullsLast()}
+	expected := []KeySetColumn{KeySetDesc("CreatedAt"), KeySetAsc("Priority").NullsLast(), KeySetDesc("ID").NullsFirst()}
+	assert.Equal(t, expected, reverseKeySet(keyset))
+	assert.Equal(t, keyset, reverseKeySet(reverseKeySet(keyset)))
+}
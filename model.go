@@ -0,0 +1,165 @@
+package goqux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+)
+
+// ErrMissingTableName is returned by the Model functions when the table name of the model can't be resolved.
+var ErrMissingTableName = errors.New("goqux: missing table name")
+
+// Tabler is implemented by models that know their table name, the name may be schema qualified i.e. billing.invoices.
+type Tabler interface {
+	TableName() string
+}
+
+// TableNameOf returns the table name of T, from its TableName method if T implements Tabler, or from the
+// goqux:"table=..." tag of a blank marker field:
+//
+//	type Invoice struct {
+//		_  struct{} `goqux:"table=billing.invoices"`
+//		ID int64
+//	}
+func TableNameOf[T any]() (string, error) {
+	if tabler, ok := any(new(T)).(Tabler); ok {
+		return tabler.TableName(), nil
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	metadata, err := getStructMetadata(t)
+	if err != nil {
+		return "", err
+	}
+	if metadata.table == "" {
+		return "", fmt.Errorf("%w: %s doesn't implement Tabler or set a goqux:\"table=...\" tag", ErrMissingTableName, t)
+	}
+	return metadata.table, nil
+}
+
+// SelectModel is like Select, with the table name of T.
+func SelectModel[T any](ctx context.Context, querier Querier, options ...SelectOption) ([]T, error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return Select[T](ctx, querier, tableName, options...)
+}
+
+// SelectOneModel is like SelectOne, with the table name of T.
+func SelectOneModel[T any](ctx context.Context, querier Querier, options ...SelectOption) (T, error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return SelectOne[T](ctx, querier, tableName, options...)
+}
+
+// SelectByPKModel is like SelectByPK, with the table name of T.
+func SelectByPKModel[T any](ctx context.Context, querier Querier, key any, options ...SelectOption) (T, error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return SelectByPK[T](ctx, querier, tableName, key, options...)
+}
+
+// SelectEachModel is like SelectEach, with the table name of T.
+func SelectEachModel[T any](ctx context.Context, querier Querier, fn func(T) error, options ...SelectOption) error {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return err
+	}
+	return SelectEach(ctx, querier, tableName, fn, options...)
+}
+
+// SelectSeqModel is like SelectSeq, with the table name of T.
+func SelectSeqModel[T any](ctx context.Context, querier Querier, options ...SelectOption) iter.Seq2[T, error] {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return func(yield func(T, error) bool) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+	return SelectSeq[T](ctx, querier, tableName, options...)
+}
+
+// SelectPaginationModel is like SelectPagination, with the table name of T.
+func SelectPaginationModel[T any](ctx context.Context, querier Querier, paginationOptions *PaginationOptions, options ...SelectOption) (*Paginator[T], error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return SelectPagination[T](ctx, querier, tableName, paginationOptions, options...)
+}
+
+// InsertModel is like Insert, with the table name of T.
+func InsertModel[T any](ctx context.Context, querier Querier, insertValue T, options ...InsertOption) (*T, error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return Insert[T](ctx, querier, tableName, insertValue, options...)
+}
+
+// InsertManyModel is like InsertMany, with the table name of T.
+func InsertManyModel[T any](ctx context.Context, querier Querier, insertValues []T, options ...InsertOption) ([]T, error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(insertValues))
+	for i, v := range insertValues {
+		values[i] = v
+	}
+	return InsertMany[T](ctx, querier, tableName, values, options...)
+}
+
+// UpdateModel is like Update, with the table name of T.
+func UpdateModel[T any](ctx context.Context, querier Querier, updateValue T, options ...UpdateOption) ([]T, error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return Update[T](ctx, querier, tableName, updateValue, options...)
+}
+
+// UpdateByPKModel is like UpdateByPK, with the table name of T.
+func UpdateByPKModel[T any](ctx context.Context, querier Querier, updateValue T, options ...UpdateOption) ([]T, error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return UpdateByPK[T](ctx, querier, tableName, updateValue, options...)
+}
+
+// DeleteModel is like Delete, with the table name of T.
+func DeleteModel[T any](ctx context.Context, querier Querier, options ...DeleteOption) ([]T, error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return Delete[T](ctx, querier, tableName, options...)
+}
+
+// DeleteByPKModel is like DeleteByPK, with the table name of T.
+func DeleteByPKModel[T any](ctx context.Context, querier Querier, key any, options ...DeleteOption) ([]T, error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return DeleteByPK[T](ctx, querier, tableName, key, options...)
+}
+
+// NewModelRepository is like NewRepository, with the table name of T.
+func NewModelRepository[T any](querier Querier, options ...RepositoryOption) (*Repository[T], error) {
+	tableName, err := TableNameOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return NewRepository[T](querier, tableName, options...), nil
+}
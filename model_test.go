@@ -0,0 +1,131 @@
+package goqux_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/roneli/goqux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tablerModel struct {
+	ID int64 `goqux:"pk"`
+}
+
+func (tablerModel) TableName() string {
+	return "tabler_models"
+}
+
+type pointerTablerModel struct {
+	ID int64
+}
+
+func (*pointerTablerModel) TableName() string {
+	return "pointer_tabler_models"
+}
+
+type invoice struct {
+	_      struct{} `goqux:"table=billing.invoices"`
+	ID     int64    `goqux:"pk"`
+	Amount int64
+}
+
+func TestTableNameOf(t *testing.T) {
+	tableName, err := goqux.TableNameOf[tablerModel]()
+	require.NoError(t, err)
+	assert.Equal(t, "tabler_models", tableName)
+
+	tableName, err = goqux.TableNameOf[pointerTablerModel]()
+	require.NoError(t, err)
+	assert.Equal(t, "pointer_tabler_models", tableName)
+
+	tableName, err = goqux.TableNameOf[invoice]()
+	require.NoError(t, err)
+	assert.Equal(t, "billing.invoices", tableName)
+
+	_, err = goqux.TableNameOf[selectModel]()
+	assert.ErrorIs(t, err, goqux.ErrMissingTableName)
+
+	_, err = goqux.TableNameOf[struct {
+		_ struct{} `goqux:"table=a"`
+		_ struct{} `goqux:"table=b"`
+	}]()
+	assert.ErrorIs(t, err, goqux.ErrInvalidTag)
+
+	_, err = goqux.TableNameOf[struct {
+		ID int64 `goqux:"table=a"`
+	}]()
+	assert.ErrorIs(t, err, goqux.ErrInvalidTag)
+}
+
+func TestModelFunctions(t *testing.T) {
+	ctx := context.Background()
+	tableTests := []struct {
+		name          string
+		run           func(querier *recordingQuerier) error
+		expectedQuery string
+		expectedArgs  []any
+	}{
+		{
+			name: "select",
+			run: func(querier *recordingQuerier) error {
+				_, err := goqux.SelectModel[invoice](ctx, querier, goqux.WithSelectFilters(goqux.Column("billing.invoices", "amount").Gt(10)))
+				return err
+			},
+			expectedQuery: `SELECT "billing"."invoices"."id", "billing"."invoices"."amount" FROM "billing"."invoices" WHERE ("billing"."invoices"."amount" > $1)`,
+			expectedArgs:  []any{int64(10)},
+		},
+		{
+			name: "select_by_pk",
+			run: func(querier *recordingQuerier) error {
+				_, err := goqux.SelectByPKModel[tablerModel](ctx, querier, 1)
+				return err
+			},
+			expectedQuery: `SELECT "tabler_models"."id" FROM "tabler_models" WHERE ("tabler_models"."id" = $1)`,
+			expectedArgs:  []any{int64(1)},
+		},
+		{
+			name: "insert",
+			run: func(querier *recordingQuerier) error {
+				_, err := goqux.InsertModel(ctx, querier, invoice{ID: 1, Amount: 10})
+				return err
+			},
+			expectedQuery: `INSERT INTO "billing"."invoices" ("amount", "id") VALUES ($1, $2)`,
+			expectedArgs:  []any{int64(10), int64(1)},
+		},
+		{
+			name: "update_by_pk",
+			run: func(querier *recordingQuerier) error {
+				_, err := goqux.UpdateByPKModel(ctx, querier, invoice{ID: 1, Amount: 20})
+				return err
+			},
+			expectedQuery: `UPDATE "billing"."invoices" SET "amount"=$1 WHERE ("billing"."invoices"."id" = $2)`,
+			expectedArgs:  []any{int64(20), int64(1)},
+		},
+		{
+			name: "delete",
+			run: func(querier *recordingQuerier) error {
+				_, err := goqux.DeleteModel[invoice](ctx, querier)
+				return err
+			},
+			expectedQuery: `DELETE FROM "billing"."invoices"`,
+			expectedArgs:  []any{},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			querier := &recordingQuerier{}
+			assert.ErrorIs(t, tt.run(querier), errRecorded)
+			assert.Equal(t, []string{tt.expectedQuery}, querier.queries)
+			assert.Equal(t, [][]any{tt.expectedArgs}, querier.args)
+		})
+	}
+}
+
+func TestModelFunctionsWithoutTableName(t *testing.T) {
+	_, err := goqux.SelectModel[selectModel](context.Background(), &recordingQuerier{})
+	assert.ErrorIs(t, err, goqux.ErrMissingTableName)
+	_, err = goqux.NewModelRepository[selectModel](&recordingQuerier{})
+	assert.ErrorIs(t, err, goqux.ErrMissingTableName)
+}
@@ -0,0 +1,470 @@
+package goqux
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/iancoleman/strcase"
+)
+
+type PaginationOptions struct {
+	// PageSize per page (default: 10)
+	PageSize uint
+	// Use columns for key filtering, this will add a WithKeySet option to the query,
+	// keys aren't validated, so make sure the names are correct or query will fail
+	// if KeySet isn't set, pagination will use offset instead.
+	KeySet []string
+	// KeySetColumns is like KeySet but allows setting the direction and NULL ordering of each column,
+	// i.e. []KeySetColumn{KeySetDesc("CreatedAt"), KeySetDesc("ID")} for newest first, takes precedence over KeySet.
+	KeySetColumns []KeySetColumn
+	// CursorKey signs the cursors returned by Paginator.Cursor with HMAC-SHA256 and verifies them on resume,
+	// if not set cursors are only base64 encoded and can be modified by the client.
+	CursorKey []byte
+	// CountTotal runs a COUNT(*) over the filtered query once per paginator, with the first page,
+	// making Paginator.TotalCount and Paginator.TotalPages available.
+	CountTotal bool
+	// Prefetch fetches up to Prefetch pages ahead in a background goroutine, so the database IO of the next pages overlaps
+	// with processing the current page. The querier must be safe for concurrent use (i.e. *pgxpool.Pool) if it's used
+	// while paginating, call Paginator.Close or cancel the context to stop prefetching early.
+	Prefetch uint
+}
+
+func (o *PaginationOptions) keySet() []KeySetColumn {
+	if len(o.KeySetColumns) > 0 {
+		return o.KeySetColumns
+	}
+	if len(o.KeySet) > 0 {
+		return keySetFromNames(o.KeySet)
+	}
+	return nil
+}
+
+// PageIterator is a function that returns a page of results and a boolean indicating if there should be a next page or to stop iterating.
+type PageIterator[T any] func(p *Paginator[T]) ([]T, bool, error)
+
+// Paginator allows to paginate over result set of T
+type Paginator[T any] struct {
+	hasNext     bool
+	hasPrevious bool
+	iterator    PageIterator[T]
+	previous    PageIterator[T]
+	offset      uint
+	pageOffset  uint
+	values      []any
+	firstValues []any
+	stop        bool
+	cursorKey   []byte
+	pageSize    uint
+	page        uint
+	counter     func() (uint64, error)
+	totalCount  uint64
+	closer      func() error
+	// prefetching state, see PaginationOptions.Prefetch
+	ctx           context.Context
+	prefetchPages uint
+	prefetcher    *prefetcher[T]
+}
+
+func NewPaginator[T any](iterator PageIterator[T]) *Paginator[T] {
+	return &Paginator[T]{
+		hasNext:  true,
+		iterator: iterator,
+		offset:   0,
+		values:   nil,
+		stop:     false,
+	}
+}
+
+func (p *Paginator[T]) HasMorePages() bool {
+	return p.hasNext && !p.stop
+}
+
+// HasPreviousPages returns true if there are pages before the current page.
+func (p *Paginator[T]) HasPreviousPages() bool {
+	return p.hasPrevious && p.previous != nil
+}
+
+func (p *Paginator[T]) NextPage() ([]T, error) {
+	if err := p.count(); err != nil {
+		return nil, err
+	}
+	if p.prefetchPages > 0 {
+		data, err := p.nextPrefetchedPage()
+		if len(data) > 0 {
+			p.page++
+		}
+		return data, err
+	}
+	data, shouldStop, err := p.iterator(p)
+	if shouldStop {
+		p.stop = true
+	}
+	if len(data) > 0 {
+		p.page++
+	}
+	return data, err
+}
+
+// PreviousPage returns the page before the current page, rows are returned in the same order NextPage returns them.
+func (p *Paginator[T]) PreviousPage() ([]T, error) {
+	if p.previous == nil {
+		return nil, fmt.Errorf("goqux: paginator doesn't support previous pages")
+	}
+	if !p.hasPrevious {
+		return []T{}, nil
+	}
+	// pages prefetched after the current page are no longer valid
+	p.stopPrefetch()
+	if err := p.count(); err != nil {
+		return nil, err
+	}
+	data, shouldStop, err := p.previous(p)
+	if shouldStop {
+		p.stop = true
+	}
+	if len(data) > 0 && p.page > 1 {
+		p.page--
+	}
+	return data, err
+}
+
+// Close releases the resources held by the paginator, such as the transaction of a server cursor pagination,
+// it is safe to call Close more than once.
+func (p *Paginator[T]) Close() error {
+	p.stopPrefetch()
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer()
+}
+
+// TotalCount returns the total number of rows, it is only available with PaginationOptions.CountTotal
+// after the first page was fetched, otherwise zero is returned.
+func (p *Paginator[T]) TotalCount() uint64 {
+	return p.totalCount
+}
+
+// TotalPages returns the total number of pages, it is only available with PaginationOptions.CountTotal
+// after the first page was fetched, otherwise zero is returned.
+func (p *Paginator[T]) TotalPages() uint {
+	if p.pageSize == 0 {
+		return 0
+	}
+	return uint((p.totalCount + uint64(p.pageSize) - 1) / uint64(p.pageSize))
+}
+
+// CurrentPage returns the 1-based number of the last fetched page, or zero if no page was fetched yet.
+func (p *Paginator[T]) CurrentPage() uint {
+	return p.page
+}
+
+// count runs the total count query once per paginator.
+func (p *Paginator[T]) count() error {
+	if p.counter == nil {
+		return nil
+	}
+	total, err := p.counter()
+	if err != nil {
+		return err
+	}
+	p.totalCount = total
+	p.counter = nil
+	return nil
+}
+
+// Cursor returns an opaque token of the paginator position, which can be passed to SelectPaginationFromCursor
+// or QueryKeySetPaginationFromCursor to resume from the next page. An empty cursor is returned if there are no more pages.
+func (p *Paginator[T]) Cursor() (string, error) {
+	if !p.HasMorePages() {
+		return "", nil
+	}
+	return p.encodeCursor(p.values, p.offset)
+}
+
+// PreviousCursor is like Cursor, but the resumed paginator continues from the page before the current page by calling PreviousPage.
+// An empty cursor is returned if there are no previous pages.
+func (p *Paginator[T]) PreviousCursor() (string, error) {
+	if !p.HasPreviousPages() {
+		return "", nil
+	}
+	return p.encodeCursor(nil, 0)
+}
+
+func (p *Paginator[T]) encodeCursor(after []any, offset uint) (string, error) {
+	values, err := marshalCursorValues(after)
+	if err != nil {
+		return "", err
+	}
+	var before []json.RawMessage
+	if p.hasPrevious {
+		if before, err = marshalCursorValues(p.firstValues); err != nil {
+			return "", err
+		}
+	}
+	return encodeCursor(cursor{Values: values, Before: before, Offset: offset, PageOffset: p.pageOffset, Page: p.page}, p.cursorKey)
+}
+
+// resume moves the paginator to the position of the given cursor.
+func (p *Paginator[T]) resume(token string, keyset []KeySetColumn) error {
+	c, err := decodeCursor(token, p.cursorKey)
+	if err != nil {
+		return err
+	}
+	if keyset == nil && (c.Values != nil || c.Before != nil) {
+		return fmt.Errorf("%w: keyset cursor used with offset pagination", ErrInvalidCursor)
+	}
+	values, err := unmarshalCursorValues[T](c.Values, keyset)
+	if err != nil {
+		return err
+	}
+	firstValues, err := unmarshalCursorValues[T](c.Before, keyset)
+	if err != nil {
+		return err
+	}
+	p.values = values
+	p.firstValues = firstValues
+	p.offset = c.Offset
+	p.pageOffset = c.PageOffset
+	p.page = c.Page
+	p.hasPrevious = c.Before != nil || c.PageOffset > 0
+	return nil
+}
+
+func (p *Paginator[T]) applyOptions(ctx context.Context, paginationOptions *PaginationOptions) {
+	p.ctx = ctx
+	p.cursorKey = paginationOptions.CursorKey
+	p.pageSize = paginationOptions.PageSize
+	p.prefetchPages = paginationOptions.Prefetch
+}
+
+// setKeySetPage moves the paginator keyset position to the given page.
+func (p *Paginator[T]) setKeySetPage(results []T, keyset []KeySetColumn) error {
+	if len(results) == 0 {
+		return nil
+	}
+	firstValues, err := keySetValues(results[0], keyset)
+	if err != nil {
+		return err
+	}
+	values, err := keySetValues(results[len(results)-1], keyset)
+	if err != nil {
+		return err
+	}
+	p.firstValues, p.values = firstValues, values
+	return nil
+}
+
+func SelectPagination[T any](ctx context.Context, querier Querier, tableName string, paginationOptions *PaginationOptions, options ...SelectOption) (*Paginator[T], error) {
+	if paginationOptions == nil {
+		paginationOptions = &PaginationOptions{
+			PageSize: 10,
+		}
+	}
+	pageSize := paginationOptions.PageSize
+	keyset := paginationOptions.keySet()
+	p := NewPaginator(func(p *Paginator[T]) ([]T, bool, error) {
+		var pageOptions []SelectOption
+		if keyset != nil {
+			pageOptions = []SelectOption{WithKeySetColumns(keyset, p.values)}
+		} else {
+			pageOptions = []SelectOption{WithSelectOffset(p.offset)}
+		}
+		results, err := Select[T](ctx, querier, tableName, append(append(options[:len(options):len(options)], pageOptions...), WithSelectLimit(pageSize))...)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(results) > 0 {
+			if keyset != nil {
+				p.hasPrevious = p.values != nil
+				if err := p.setKeySetPage(results, keyset); err != nil {
+					return nil, false, err
+				}
+			} else {
+				p.hasPrevious = p.offset > 0
+				p.pageOffset = p.offset
+			}
+		}
+		if len(results) == 0 || len(results) < int(pageSize) {
+			p.hasNext = false
+			return results, false, nil
+		}
+		if keyset == nil {
+			p.offset += pageSize
+		}
+		return results, false, nil
+	})
+	p.previous = func(p *Paginator[T]) ([]T, bool, error) {
+		var pageOptions []SelectOption
+		start := uint(0)
+		if keyset != nil {
+			// fetch one more row to know if there are pages before the previous page
+			pageOptions = []SelectOption{WithKeySetColumns(reverseKeySet(keyset), p.firstValues), WithSelectLimit(pageSize + 1)}
+		} else {
+			if p.pageOffset > pageSize {
+				start = p.pageOffset - pageSize
+			}
+			pageOptions = []SelectOption{WithSelectOffset(start), WithSelectLimit(p.pageOffset - start)}
+		}
+		results, err := Select[T](ctx, querier, tableName, append(options[:len(options):len(options)], pageOptions...)...)
+		if err != nil {
+			return nil, false, err
+		}
+		if keyset != nil {
+			p.hasPrevious = len(results) > int(pageSize)
+			results = reversePage(results, pageSize)
+			if err := p.setKeySetPage(results, keyset); err != nil {
+				return nil, false, err
+			}
+		} else {
+			p.pageOffset = start
+			p.offset = start + uint(len(results))
+			p.hasPrevious = start > 0
+		}
+		p.hasNext = true
+		return results, false, nil
+	}
+	p.applyOptions(ctx, paginationOptions)
+	if paginationOptions.CountTotal {
+		p.counter = func() (uint64, error) {
+			return countRows(ctx, querier, tableName, buildSelectDataset(tableName, new(T), options...), reflect.TypeFor[T]())
+		}
+	}
+	return p, nil
+}
+
+// SelectPaginationFromCursor is like SelectPagination, but resumes from a cursor returned by Paginator.Cursor or Paginator.PreviousCursor,
+// the pagination options and select options must be the same as the ones used to create the cursor.
+func SelectPaginationFromCursor[T any](ctx context.Context, querier Querier, tableName string, cursor string, paginationOptions *PaginationOptions, options ...SelectOption) (*Paginator[T], error) {
+	p, err := SelectPagination[T](ctx, querier, tableName, paginationOptions, options...)
+	if err != nil {
+		return nil, err
+	}
+	var keyset []KeySetColumn
+	if paginationOptions != nil {
+		keyset = paginationOptions.keySet()
+	}
+	if err := p.resume(cursor, keyset); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// QueryKeySetPagination is a helper function to paginate over a query using keyset pagination.
+func QueryKeySetPagination[T any](ctx context.Context, querier Querier, sd *goqu.SelectDataset, pageSize uint, keyset []string) (*Paginator[T], error) {
+	return QueryKeySetPaginationWithOptions[T](ctx, querier, sd, &PaginationOptions{
+		PageSize: pageSize,
+		KeySet:   keyset,
+	})
+}
+
+// QueryKeySetPaginationWithOptions is like QueryKeySetPagination, but takes the keyset (KeySet or KeySetColumns) and page size from paginationOptions.
+func QueryKeySetPaginationWithOptions[T any](ctx context.Context, querier Querier, sd *goqu.SelectDataset, paginationOptions *PaginationOptions) (*Paginator[T], error) {
+	if paginationOptions == nil {
+		return nil, fmt.Errorf("goqux: pagination options are required for keyset pagination")
+	}
+	keyset := paginationOptions.keySet()
+	if len(keyset) == 0 {
+		return nil, fmt.Errorf("goqux: keyset is required for pagination")
+	}
+	// the keyset values are read from the rows with the metadata of T, which isn't used to build the query
+	if _, err := getStructMetadata(reflect.TypeFor[T]()); err != nil {
+		return nil, err
+	}
+	pageSize := paginationOptions.PageSize
+	cols := make([]exp.IdentifierExpression, len(keyset))
+	for i, c := range keyset {
+		cols[i] = goqu.C(strcase.ToSnake(c.Name))
+	}
+	fetch := func(keyset []KeySetColumn, values []any, limit uint) ([]T, error) {
+		pageQuery := sd.Limit(limit).ClearOffset().Order(keySetOrder(keyset, cols)...)
+		if values != nil {
+			pageQuery = pageQuery.Where(keySetPredicate(pageQuery.Dialect().Dialect(), keyset, cols, values))
+		}
+		query, args, err := pageQuery.ToSQL()
+		if err != nil {
+			return nil, fmt.Errorf("goqux: failed to build select query: %w", err)
+		}
+		results := make([]T, 0)
+		if err := executorOf(querier).Select(ctx, &results, query, args...); err != nil {
+			return nil, newQueryError("select", "", query, args, redactedSelect(pageQuery, reflect.TypeFor[T]()), err)
+		}
+		return results, nil
+	}
+	p := NewPaginator(func(p *Paginator[T]) ([]T, bool, error) {
+		results, err := fetch(keyset, p.values, pageSize)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(results) > 0 {
+			p.hasPrevious = p.values != nil
+			if err := p.setKeySetPage(results, keyset); err != nil {
+				return nil, false, err
+			}
+		}
+		if len(results) == 0 || len(results) < int(pageSize) {
+			p.hasNext = false
+		}
+		return results, false, nil
+	})
+	p.previous = func(p *Paginator[T]) ([]T, bool, error) {
+		// fetch one more row to know if there are pages before the previous page
+		results, err := fetch(reverseKeySet(keyset), p.firstValues, pageSize+1)
+		if err != nil {
+			return nil, false, err
+		}
+		p.hasPrevious = len(results) > int(pageSize)
+		results = reversePage(results, pageSize)
+		if err := p.setKeySetPage(results, keyset); err != nil {
+			return nil, false, err
+		}
+		p.hasNext = true
+		return results, false, nil
+	}
+	p.applyOptions(ctx, paginationOptions)
+	if paginationOptions.CountTotal {
+		p.counter = func() (uint64, error) {
+			return countRows(ctx, querier, "", sd, reflect.TypeFor[T]())
+		}
+	}
+	return p, nil
+}
+
+// QueryKeySetPaginationFromCursor is like QueryKeySetPaginationWithOptions, but resumes from a cursor returned by Paginator.Cursor
+// or Paginator.PreviousCursor.
+func QueryKeySetPaginationFromCursor[T any](ctx context.Context, querier Querier, sd *goqu.SelectDataset, cursor string, paginationOptions *PaginationOptions) (*Paginator[T], error) {
+	p, err := QueryKeySetPaginationWithOptions[T](ctx, querier, sd, paginationOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.resume(cursor, paginationOptions.keySet()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// reversePage truncates rows fetched in reverse order to the page size and reverses them back into display order.
+func reversePage[T any](results []T, pageSize uint) []T {
+	if len(results) > int(pageSize) {
+		results = results[:pageSize]
+	}
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+	return results
+}
+
+func countRows(ctx context.Context, querier Querier, tableName string, sd *goqu.SelectDataset, model reflect.Type) (uint64, error) {
+	query, args, err := buildCountQuery(sd)
+	if err != nil {
+		return 0, fmt.Errorf("goqux: failed to build count query: %w", err)
+	}
+	var count int64
+	if err := executorOf(querier).Get(ctx, &count, query, args...); err != nil {
+		return 0, newQueryError("count", tableName, query, args, redactedCount(sd, model), err)
+	}
+	return uint64(count), nil
+}
@@ -0,0 +1,93 @@
+package goqux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReversePage(t *testing.T) {
+	tableTests := []struct {
+		name     string
+		results  []int
+		pageSize uint
+		expected []int
+	}{
+		{
+			name:     "empty",
+			results:  []int{},
+			pageSize: 2,
+			expected: []int{},
+		},
+		{
+			name:     "partial_page",
+			results:  []int{3, 2},
+			pageSize: 3,
+			expected: []int{2, 3},
+		},
+		{
+			name:     "extra_row",
+			results:  []int{5, 4, 3},
+			pageSize: 2,
+			expected: []int{4, 5},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, reversePage(tt.results, tt.pageSize))
+		})
+	}
+}
+
+func TestPreviousPageNotSupported(t *testing.T) {
+	p := NewPaginator(func(p *Paginator[int]) ([]int, bool, error) {
+		return []int{1}, true, nil
+	})
+	assert.False(t, p.HasPreviousPages())
+	_, err := p.PreviousPage()
+	assert.Error(t, err)
+}
+
+func TestServerCursorPaginationRequiresPgx(t *testing.T) {
+	_, err := SelectServerCursorPagination[querierModel](context.Background(), newFakeSQLQuerier(t, &fakeDriver{}), "models", nil)
+	assert.EqualError(t, err, "goqux: server cursor pagination requires a *pgxpool.Pool or a pgx.Tx, got *goqux.SQLQuerier")
+}
+
+func TestBuildCountQuery(t *testing.T) {
+	sd := buildSelectDataset("users", struct{ ID int64 }{},
+		WithSelectFilters(Column("users", "id").Gt(5)),
+		WithSelectOrder(Column("users", "id").Desc()),
+		WithSelectLimit(10),
+		WithSelectOffset(20),
+	)
+	query, args, err := buildCountQuery(sd)
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT COUNT(*) FROM (SELECT "users"."id" FROM "users" WHERE ("users"."id" > $1)) AS "goqux_count"`, query)
+	assert.Equal(t, []any{int64(5)}, args)
+}
+
+func TestPaginatorPageMetadata(t *testing.T) {
+	pages := [][]int{{1, 2}, {3, 4}, {5}}
+	counted := 0
+	p := NewPaginator(func(p *Paginator[int]) ([]int, bool, error) {
+		page := pages[p.offset]
+		p.offset++
+		p.hasNext = int(p.offset) < len(pages)
+		return page, false, nil
+	})
+	p.pageSize = 2
+	p.counter = func() (uint64, error) {
+		counted++
+		return 5, nil
+	}
+	assert.Equal(t, uint(0), p.CurrentPage())
+	for p.HasMorePages() {
+		_, err := p.NextPage()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(5), p.TotalCount())
+		assert.Equal(t, uint(3), p.TotalPages())
+		assert.Equal(t, uint(p.offset), p.CurrentPage())
+	}
+	assert.Equal(t, 1, counted)
+}
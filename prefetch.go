@@ -0,0 +1,122 @@
+package goqux
+
+import (
+	"context"
+)
+
+// paginatorState is a snapshot of the position of a Paginator.
+type paginatorState struct {
+	hasNext     bool
+	hasPrevious bool
+	stop        bool
+	offset      uint
+	pageOffset  uint
+	values      []any
+	firstValues []any
+}
+
+func (p *Paginator[T]) state() paginatorState {
+	return paginatorState{
+		hasNext:     p.hasNext,
+		hasPrevious: p.hasPrevious,
+		stop:        p.stop,
+		offset:      p.offset,
+		pageOffset:  p.pageOffset,
+		values:      p.values,
+		firstValues: p.firstValues,
+	}
+}
+
+func (p *Paginator[T]) setState(s paginatorState) {
+	p.hasNext = s.hasNext
+	p.hasPrevious = s.hasPrevious
+	p.stop = s.stop
+	p.offset = s.offset
+	p.pageOffset = s.pageOffset
+	p.values = s.values
+	p.firstValues = s.firstValues
+}
+
+type prefetchedPage[T any] struct {
+	data  []T
+	err   error
+	state paginatorState
+}
+
+// prefetcher fetches pages ahead of the paginator in a background goroutine, using a copy of the paginator
+// so the position of the paginator only moves when a prefetched page is consumed.
+type prefetcher[T any] struct {
+	pages  chan prefetchedPage[T]
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (p *Paginator[T]) startPrefetch() {
+	ctx, cancel := context.WithCancel(p.ctx)
+	pf := &prefetcher[T]{
+		// the goroutine holds one more page while blocked on a full buffer, so at most prefetchPages are fetched ahead
+		pages:  make(chan prefetchedPage[T], p.prefetchPages-1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	inner := &Paginator[T]{iterator: p.iterator}
+	inner.setState(p.state())
+	go func() {
+		defer close(pf.done)
+		defer close(pf.pages)
+		for inner.HasMorePages() {
+			data, shouldStop, err := inner.iterator(inner)
+			if shouldStop {
+				inner.stop = true
+			}
+			select {
+			case pf.pages <- prefetchedPage[T]{data: data, err: err, state: inner.state()}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	p.prefetcher = pf
+}
+
+// nextPrefetchedPage returns the next page from the prefetch buffer, starting the prefetching goroutine if needed.
+func (p *Paginator[T]) nextPrefetchedPage() ([]T, error) {
+	if err := p.ctx.Err(); err != nil {
+		p.stopPrefetch()
+		return nil, err
+	}
+	if p.prefetcher == nil {
+		p.startPrefetch()
+	}
+	select {
+	case page, ok := <-p.prefetcher.pages:
+		if !ok {
+			p.stopPrefetch()
+			p.hasNext = false
+			return []T{}, nil
+		}
+		p.setState(page.state)
+		if page.err != nil || !p.HasMorePages() {
+			// the goroutine is done, on error prefetching restarts from the current position on the next call
+			p.stopPrefetch()
+		}
+		return page.data, page.err
+	case <-p.ctx.Done():
+		p.stopPrefetch()
+		return nil, p.ctx.Err()
+	}
+}
+
+// stopPrefetch stops the prefetching goroutine and drops any pages that weren't consumed, the paginator position
+// stays at the last consumed page.
+func (p *Paginator[T]) stopPrefetch() {
+	if p.prefetcher == nil {
+		return
+	}
+	p.prefetcher.cancel()
+	<-p.prefetcher.done
+	p.prefetcher = nil
+}
@@ -0,0 +1,91 @@
+package goqux
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCountingPaginator returns a paginator over total pages of one row each, failing once on failAt if set.
+func newCountingPaginator(ctx context.Context, total int, prefetch uint, failAt int) (*Paginator[int], *atomic.Int32) {
+	var calls atomic.Int32
+	failed := false
+	p := NewPaginator(func(p *Paginator[int]) ([]int, bool, error) {
+		calls.Add(1)
+		if int(p.offset) == failAt && !failed {
+			failed = true
+			return nil, false, errors.New("failed")
+		}
+		page := []int{int(p.offset)}
+		p.offset++
+		p.hasNext = int(p.offset) < total
+		return page, false, nil
+	})
+	p.ctx = ctx
+	p.prefetchPages = prefetch
+	return p, &calls
+}
+
+func TestPrefetchPages(t *testing.T) {
+	p, calls := newCountingPaginator(context.Background(), 10, 3, -1)
+	rows := make([]int, 0)
+	for p.HasMorePages() {
+		page, err := p.NextPage()
+		require.NoError(t, err)
+		rows = append(rows, page...)
+		if len(rows) == 1 {
+			// the first page is consumed, the next 3 pages are fetched in the background and no more
+			assert.Eventually(t, func() bool { return calls.Load() == 4 }, time.Second, time.Millisecond)
+			time.Sleep(10 * time.Millisecond)
+			assert.Equal(t, int32(4), calls.Load())
+			assert.Equal(t, uint(1), p.offset)
+		}
+	}
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, rows)
+	assert.Equal(t, uint(10), p.CurrentPage())
+	assert.Nil(t, p.prefetcher)
+}
+
+func TestPrefetchError(t *testing.T) {
+	p, _ := newCountingPaginator(context.Background(), 5, 2, 2)
+	rows := make([]int, 0)
+	errs := 0
+	for p.HasMorePages() {
+		page, err := p.NextPage()
+		if err != nil {
+			errs++
+			continue
+		}
+		rows = append(rows, page...)
+	}
+	assert.Equal(t, 1, errs)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, rows)
+}
+
+func TestPrefetchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p, _ := newCountingPaginator(ctx, 100, 2, -1)
+	_, err := p.NextPage()
+	require.NoError(t, err)
+	cancel()
+	_, err = p.NextPage()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoError(t, p.Close())
+	assert.Nil(t, p.prefetcher)
+}
+
+func TestPrefetchClose(t *testing.T) {
+	p, calls := newCountingPaginator(context.Background(), 100, 2, -1)
+	_, err := p.NextPage()
+	require.NoError(t, err)
+	require.NoError(t, p.Close())
+	stopped := calls.Load()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, stopped, calls.Load())
+	assert.Equal(t, uint(1), p.offset)
+}
@@ -0,0 +1,179 @@
+package goqux
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/georgysavva/scany/v2/sqlscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Querier runs the goqux queries, it is implemented by *pgx.Conn, *pgxpool.Pool and pgx.Tx.
+// Use NewSQLQuerier for database/sql and sqlx connections and transactions.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// SQLQuerier adapts a database/sql *sql.DB, *sql.Tx or *sql.Conn (or their sqlx counterparts) to a Querier,
+// goqux scans its rows with scany's sqlscan.
+type SQLQuerier struct {
+	db sqlscan.Querier
+}
+
+// NewSQLQuerier returns a Querier running queries on the given database/sql connection or transaction.
+func NewSQLQuerier(db sqlscan.Querier) *SQLQuerier {
+	return &SQLQuerier{db: db}
+}
+
+// Query runs the query with database/sql, the returned rows support scanning and reading the values of each row,
+// but have no command tag, raw values or pgx connection.
+func (q *SQLQuerier) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlRows{rows: rows}, nil
+}
+
+// sqlRows exposes *sql.Rows as pgx.Rows.
+type sqlRows struct {
+	rows *sql.Rows
+	err  error
+}
+
+func (r *sqlRows) Close() {
+	_ = r.rows.Close()
+}
+
+func (r *sqlRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+func (r *sqlRows) CommandTag() pgconn.CommandTag {
+	return pgconn.CommandTag{}
+}
+
+func (r *sqlRows) FieldDescriptions() []pgconn.FieldDescription {
+	columns, err := r.rows.Columns()
+	if err != nil {
+		r.err = err
+		return nil
+	}
+	fields := make([]pgconn.FieldDescription, len(columns))
+	for i, c := range columns {
+		fields[i] = pgconn.FieldDescription{Name: c}
+	}
+	return fields
+}
+
+func (r *sqlRows) Next() bool {
+	return r.rows.Next()
+}
+
+func (r *sqlRows) Scan(dest ...any) error {
+	return r.rows.Scan(dest...)
+}
+
+func (r *sqlRows) Values() ([]any, error) {
+	columns, err := r.rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (r *sqlRows) RawValues() [][]byte {
+	return nil
+}
+
+func (r *sqlRows) Conn() *pgx.Conn {
+	return nil
+}
+
+// executor runs queries and scans their rows into structs, with pgxscan for pgx queriers and sqlscan for database/sql.
+type executor interface {
+	Select(ctx context.Context, dst any, query string, args ...any) error
+	Get(ctx context.Context, dst any, query string, args ...any) error
+	// Each calls fn for each row, with a function scanning the row into dst.
+	Each(ctx context.Context, query string, args []any, fn func(scan func(dst any) error) error) error
+}
+
+func executorOf(querier Querier) executor {
+	if q, ok := querier.(*SQLQuerier); ok {
+		return sqlExecutor{db: q.db}
+	}
+	return pgxExecutor{querier: querier}
+}
+
+type pgxExecutor struct {
+	querier Querier
+}
+
+func (e pgxExecutor) Select(ctx context.Context, dst any, query string, args ...any) error {
+	return pgxscan.Select(ctx, e.querier, dst, query, args...)
+}
+
+func (e pgxExecutor) Get(ctx context.Context, dst any, query string, args ...any) error {
+	return pgxscan.Get(ctx, e.querier, dst, query, args...)
+}
+
+func (e pgxExecutor) Each(ctx context.Context, query string, args []any, fn func(scan func(dst any) error) error) error {
+	rows, err := e.querier.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	scanner := pgxscan.NewRowScanner(rows)
+	for rows.Next() {
+		if err := fn(scanner.Scan); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+type sqlExecutor struct {
+	db sqlscan.Querier
+}
+
+func (e sqlExecutor) Select(ctx context.Context, dst any, query string, args ...any) error {
+	return sqlscan.Select(ctx, e.db, dst, query, args...)
+}
+
+func (e sqlExecutor) Get(ctx context.Context, dst any, query string, args ...any) error {
+	return sqlscan.Get(ctx, e.db, dst, query, args...)
+}
+
+func (e sqlExecutor) Each(ctx context.Context, query string, args []any, fn func(scan func(dst any) error) error) error {
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	scanner := sqlscan.NewRowScanner(rows)
+	for rows.Next() {
+		if err := fn(scanner.Scan); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// notFound returns true if err is the no rows error of pgx or database/sql.
+func notFound(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows)
+}
@@ -0,0 +1,180 @@
+package goqux
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriver is a database/sql driver and connector returning the same rows for any query, or the next error of
+// queryErrs unless it's nil, it records the queries and transactions it receives.
+type fakeDriver struct {
+	columns   []string
+	rows      [][]driver.Value
+	queryErrs []error
+	queries   []string
+	txOptions []driver.TxOptions
+	commits   int
+	rollbacks int
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{driver: d}, nil
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{driver: d}, nil
+}
+
+func (d *fakeDriver) Driver() driver.Driver {
+	return d
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.driver.txOptions = append(c.driver.txOptions, opts)
+	return fakeTx{driver: c.driver}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.driver.queries = append(c.driver.queries, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.driver.queries = append(c.driver.queries, query)
+	if len(c.driver.queryErrs) > 0 {
+		err := c.driver.queryErrs[0]
+		c.driver.queryErrs = c.driver.queryErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &fakeRows{columns: c.driver.columns, rows: c.driver.rows}, nil
+}
+
+type fakeTx struct {
+	driver *fakeDriver
+}
+
+func (t fakeTx) Commit() error {
+	t.driver.commits++
+	return nil
+}
+
+func (t fakeTx) Rollback() error {
+	t.driver.rollbacks++
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type querierModel struct {
+	ID   int64
+	Name string
+}
+
+func newFakeSQLQuerier(t *testing.T, d *fakeDriver) *SQLQuerier {
+	t.Helper()
+	db := sql.OpenDB(d)
+	t.Cleanup(func() { _ = db.Close() })
+	return NewSQLQuerier(db)
+}
+
+func TestSQLQuerier(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDriver{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}},
+	}
+	querier := newFakeSQLQuerier(t, d)
+
+	results, err := Select[querierModel](ctx, querier, "models")
+	require.NoError(t, err)
+	assert.Equal(t, []querierModel{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, results)
+	assert.Equal(t, []string{`SELECT "models"."id", "models"."name" FROM "models"`}, d.queries)
+
+	d.rows = d.rows[:1]
+	result, err := SelectOne[querierModel](ctx, querier, "models")
+	require.NoError(t, err)
+	assert.Equal(t, querierModel{ID: 1, Name: "a"}, result)
+
+	d.rows = [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}}
+	var each []querierModel
+	err = SelectEach(ctx, querier, "models", func(m querierModel) error {
+		each = append(each, m)
+		return ErrStopIteration
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []querierModel{{ID: 1, Name: "a"}}, each)
+
+	rows, err := querier.Query(ctx, "SELECT")
+	require.NoError(t, err)
+	defer rows.Close()
+	fields := rows.FieldDescriptions()
+	require.Len(t, fields, 2)
+	assert.Equal(t, "name", fields[1].Name)
+	require.True(t, rows.Next())
+	values, err := rows.Values()
+	require.NoError(t, err)
+	assert.Equal(t, []any{int64(1), "a"}, values)
+}
+
+func TestSQLQuerierNotFound(t *testing.T) {
+	ctx := context.Background()
+	querier := newFakeSQLQuerier(t, &fakeDriver{columns: []string{"id", "name"}})
+
+	_, err := SelectOne[querierModel](ctx, querier, "models")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.True(t, notFound(err))
+
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	result, err := Insert[querierModel](ctx, querier, "models", querierModel{ID: 1, Name: "a"})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	_, err = Insert[querierModel](ctx, querier, "models", querierModel{ID: 1, Name: "a"}, WithInsertReturning("id", "name"))
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,300 @@
+package goqux
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// Redaction controls how the arguments of a QueryError are redacted.
+type Redaction int
+
+const (
+	// RedactSensitive redacts the values of goqux:"sensitive" fields, whether inserted, updated or filtered on, and the
+	// filter values whose column is unknown, i.e. the arguments of a literal or a function. It's the default.
+	RedactSensitive Redaction = iota
+	// RedactAll redacts all the arguments.
+	RedactAll
+	// RedactNone keeps all the arguments.
+	RedactNone
+)
+
+// redactedArg replaces the redacted arguments of a QueryError.
+const redactedArg = "[REDACTED]"
+
+var queryErrorRedaction atomic.Int32
+
+// SetQueryErrorRedaction sets how the arguments of a QueryError are redacted, it's safe to call concurrently with queries.
+func SetQueryErrorRedaction(redaction Redaction) {
+	queryErrorRedaction.Store(int32(redaction))
+}
+
+// QueryError is returned by the execution functions when a query fails, with the query and its arguments.
+// Arguments are redacted according to SetQueryErrorRedaction, SQL is then rebuilt with placeholders so the values of a
+// query built without them, i.e. with WithInsertNotPrepared, are redacted too. Err is the translated driver error, so
+// errors.Is still matches ErrNotFound or a constraint violation.
+type QueryError struct {
+	// Op is the failed operation, i.e. select, insert, update, delete or count.
+	Op string
+	// Table is the queried table, empty when the query was built from a dataset.
+	Table string
+	SQL   string
+	Args  []any
+	Err   error
+}
+
+func (e *QueryError) Error() string {
+	if e.Table == "" {
+		return fmt.Sprintf("goqux: failed to %s: %s", e.Op, e.Err)
+	}
+	return fmt.Sprintf("goqux: failed to %s %s: %s", e.Op, e.Table, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// redactedQuery rebuilds a failed query with placeholders, with the goqux:"sensitive" values replaced by redactedArg.
+type redactedQuery func() (string, []any, error)
+
+// newQueryError returns a QueryError for the failed query, redacted rebuilds the query with its sensitive values redacted,
+// it's nil for queries that weren't built by goqux.
+func newQueryError(op, table, query string, args []any, redacted redactedQuery, err error) *QueryError {
+	query, args = redactQuery(query, args, redacted)
+	return &QueryError{
+		Op:    op,
+		Table: table,
+		SQL:   query,
+		Args:  args,
+		Err:   translateError(err),
+	}
+}
+
+// redactQuery returns the SQL and arguments of a QueryError. When arguments are redacted, the SQL is the rebuilt query,
+// so the values of a query built without placeholders don't leak through it. If the query can't be rebuilt, all the
+// arguments are redacted, and so is the SQL of a query without arguments, as its values may be inlined.
+func redactQuery(query string, args []any, redacted redactedQuery) (string, []any) {
+	result := make([]any, len(args))
+	copy(result, args)
+	redaction := Redaction(queryErrorRedaction.Load())
+	if redaction == RedactNone {
+		return query, result
+	}
+	rebuilt := false
+	if redacted != nil {
+		redactedSQL, redactedArgs, err := redacted()
+		// the rebuilt query must have the same arguments, unless the failed query was built without placeholders
+		if err == nil && (len(args) == 0 || len(redactedArgs) == len(args)) {
+			query, result, rebuilt = redactedSQL, redactedArgs, true
+		} else if len(args) == 0 {
+			query = ""
+		}
+	}
+	if rebuilt && redaction == RedactSensitive {
+		return query, result
+	}
+	for i := range result {
+		result[i] = redactedArg
+	}
+	return query, result
+}
+
+// redactedSelect returns the redactedQuery of a select dataset, see redactFilter.
+func redactedSelect(sd *goqu.SelectDataset, models ...reflect.Type) redactedQuery {
+	return func() (string, []any, error) {
+		sd, err := redactSelectFilters(sd, models)
+		if err != nil {
+			return "", nil, err
+		}
+		return sd.Prepared(true).ToSQL()
+	}
+}
+
+// redactedCount returns the redactedQuery of the count query of a select dataset.
+func redactedCount(sd *goqu.SelectDataset, models ...reflect.Type) redactedQuery {
+	return func() (string, []any, error) {
+		sd, err := redactSelectFilters(sd, models)
+		if err != nil {
+			return "", nil, err
+		}
+		return buildCountQuery(sd.Prepared(true))
+	}
+}
+
+func redactSelectFilters(sd *goqu.SelectDataset, models []reflect.Type) (*goqu.SelectDataset, error) {
+	where := sd.GetClauses().Where()
+	if where == nil {
+		return sd, nil
+	}
+	filter, err := redactFilter(where, sensitiveColumns(models...))
+	if err != nil {
+		return nil, err
+	}
+	return sd.ClearWhere().Where(filter), nil
+}
+
+// redactedDelete returns the redactedQuery of a delete dataset, see redactFilter.
+func redactedDelete(dd *goqu.DeleteDataset, models ...reflect.Type) redactedQuery {
+	return func() (string, []any, error) {
+		if where := dd.GetClauses().Where(); where != nil {
+			filter, err := redactFilter(where, sensitiveColumns(models...))
+			if err != nil {
+				return "", nil, err
+			}
+			dd = dd.ClearWhere().Where(filter)
+		}
+		return dd.Prepared(true).ToSQL()
+	}
+}
+
+// redactedUpdate returns the redactedQuery of an update, build rebuilds its dataset with the sensitive values redacted.
+func redactedUpdate(build func() (*goqu.UpdateDataset, error), models ...reflect.Type) redactedQuery {
+	return func() (string, []any, error) {
+		ud, err := build()
+		if err != nil {
+			return "", nil, err
+		}
+		if where := ud.GetClauses().Where(); where != nil {
+			filter, err := redactFilter(where, sensitiveColumns(models...))
+			if err != nil {
+				return "", nil, err
+			}
+			ud = ud.ClearWhere().Where(filter)
+		}
+		return ud.Prepared(true).ToSQL()
+	}
+}
+
+// redactedInsert returns the redactedQuery of an insert of values, with their sensitive values redacted.
+func redactedInsert(tableName string, values []any, options []InsertOption) redactedQuery {
+	return func() (string, []any, error) {
+		q, err := buildInsertDataset(tableName, values, true, options...)
+		if err != nil {
+			return "", nil, err
+		}
+		q.InsertDataset = q.InsertDataset.Prepared(true)
+		return q.ToSQL()
+	}
+}
+
+// redactFilter returns the filter with the values compared to a sensitive column replaced by redactedArg, as are the
+// values compared to anything but a column and the arguments of literals and functions, whose column is unknown. It
+// fails on the expressions it can't rebuild, i.e. sub queries.
+func redactFilter(e exp.Expression, sensitive map[string]bool) (exp.Expression, error) {
+	switch e := e.(type) {
+	case nil, exp.IdentifierExpression:
+		return e, nil
+	case exp.Ex:
+		filters, err := e.ToExpressions()
+		if err != nil {
+			return nil, err
+		}
+		return redactFilter(filters, sensitive)
+	case exp.ExOr:
+		filters, err := e.ToExpressions()
+		if err != nil {
+			return nil, err
+		}
+		return redactFilter(filters, sensitive)
+	case exp.ExpressionList:
+		filters := make([]exp.Expression, len(e.Expressions()))
+		for i, f := range e.Expressions() {
+			filter, err := redactFilter(f, sensitive)
+			if err != nil {
+				return nil, err
+			}
+			filters[i] = filter
+		}
+		return exp.NewExpressionList(e.Type(), filters...), nil
+	case exp.BooleanExpression:
+		lhs, redact, err := redactComparedTo(e.LHS(), sensitive)
+		if err != nil {
+			return nil, err
+		}
+		rhs, err := redactValue(e.RHS(), sensitive, redact)
+		if err != nil {
+			return nil, err
+		}
+		return exp.NewBooleanExpression(e.Op(), lhs, rhs), nil
+	case exp.RangeExpression:
+		lhs, redact, err := redactComparedTo(e.LHS(), sensitive)
+		if err != nil {
+			return nil, err
+		}
+		start, err := redactValue(e.RHS().Start(), sensitive, redact)
+		if err != nil {
+			return nil, err
+		}
+		end, err := redactValue(e.RHS().End(), sensitive, redact)
+		if err != nil {
+			return nil, err
+		}
+		return exp.NewRangeExpression(e.Op(), lhs, exp.NewRangeVal(start, end)), nil
+	case exp.LiteralExpression:
+		args, err := redactValues(e.Args(), sensitive)
+		if err != nil {
+			return nil, err
+		}
+		return exp.NewLiteralExpression(e.Literal(), args...), nil
+	case exp.SQLFunctionExpression:
+		args, err := redactValues(e.Args(), sensitive)
+		if err != nil {
+			return nil, err
+		}
+		return exp.NewSQLFunctionExpression(e.Name(), args...), nil
+	default:
+		return nil, fmt.Errorf("goqux: can't redact the values of %T", e)
+	}
+}
+
+// redactComparedTo redacts the left hand side of a comparison, redact is false if it's a column that isn't sensitive.
+func redactComparedTo(lhs exp.Expression, sensitive map[string]bool) (exp.Expression, bool, error) {
+	if ident, ok := lhs.(exp.IdentifierExpression); ok {
+		if column, ok := ident.GetCol().(string); ok {
+			return lhs, sensitive[column], nil
+		}
+	}
+	lhs, err := redactFilter(lhs, sensitive)
+	return lhs, true, err
+}
+
+func redactValues(values []any, sensitive map[string]bool) ([]any, error) {
+	redacted := make([]any, len(values))
+	for i, v := range values {
+		value, err := redactValue(v, sensitive, true)
+		if err != nil {
+			return nil, err
+		}
+		redacted[i] = value
+	}
+	return redacted, nil
+}
+
+// redactValue replaces v with redactedArg if redact is set, the values of a slice are replaced one by one, as it's rendered
+// as a list. NULL and boolean values are kept, as goqu renders them without a placeholder in some comparisons.
+func redactValue(v any, sensitive map[string]bool, redact bool) (any, error) {
+	switch v := v.(type) {
+	case nil, bool:
+		return v, nil
+	case exp.Expression:
+		return redactFilter(v, sensitive)
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]any, rv.Len())
+		for i := range values {
+			value, err := redactValue(rv.Index(i).Interface(), sensitive, redact)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+	if !redact || isNilPointer(v) {
+		return v, nil
+	}
+	return redactedArg, nil
+}
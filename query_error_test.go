@@ -0,0 +1,285 @@
+package goqux
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sensitiveModel struct {
+	ID       int64 `goqux:"pk"`
+	Email    string
+	Password string  `goqux:"sensitive"`
+	Token    *string `goqux:"sensitive"`
+}
+
+func TestQueryError(t *testing.T) {
+	ctx := context.Background()
+	token := "token"
+	tableTests := []struct {
+		name          string
+		redaction     Redaction
+		run           func(querier Querier) error
+		expectedOp    string
+		expectedTable string
+		expectedSQL   string
+		expectedArgs  []any
+	}{
+		{
+			name:      "insert_redact_sensitive",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := Insert[sensitiveModel](ctx, querier, "users", sensitiveModel{ID: 1, Email: "a@acme.com", Password: "secret"})
+				return err
+			},
+			expectedOp:    "insert",
+			expectedTable: "users",
+			expectedSQL:   `INSERT INTO "users" ("email", "id", "password", "token") VALUES ($1, $2, $3, $4)`,
+			expectedArgs:  []any{"a@acme.com", int64(1), redactedArg, nil},
+		},
+		{
+			name:      "insert_many_redact_sensitive",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := InsertMany[sensitiveModel](ctx, querier, "users", []any{
+					sensitiveModel{ID: 1, Email: "a@acme.com", Password: "secret"},
+					sensitiveModel{ID: 2, Email: "b@acme.com", Password: "secret", Token: &token},
+				})
+				return err
+			},
+			expectedOp:    "insert many",
+			expectedTable: "users",
+			expectedSQL:   `INSERT INTO "users" ("email", "id", "password", "token") VALUES ($1, $2, $3, $4), ($5, $6, $7, $8)`,
+			expectedArgs:  []any{"a@acme.com", int64(1), redactedArg, nil, "b@acme.com", int64(2), redactedArg, redactedArg},
+		},
+		{
+			name:      "update_by_pk_redact_sensitive",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := UpdateByPK[sensitiveModel](ctx, querier, "users", sensitiveModel{ID: 1, Password: "secret"})
+				return err
+			},
+			expectedOp:    "update",
+			expectedTable: "users",
+			expectedSQL:   `UPDATE "users" SET "password"=$1 WHERE ("users"."id" = $2)`,
+			expectedArgs:  []any{redactedArg, int64(1)},
+		},
+		{
+			name:      "update_redact_all",
+			redaction: RedactAll,
+			run: func(querier Querier) error {
+				_, err := Update[sensitiveModel](ctx, querier, "users", sensitiveModel{Email: "a@acme.com"},
+					WithUpdateFilters(Column("users", "id").Eq(1)))
+				return err
+			},
+			expectedOp:    "update",
+			expectedTable: "users",
+			expectedSQL:   `UPDATE "users" SET "email"=$1 WHERE ("users"."id" = $2)`,
+			expectedArgs:  []any{redactedArg, redactedArg},
+		},
+		{
+			name:      "insert_redact_none",
+			redaction: RedactNone,
+			run: func(querier Querier) error {
+				_, err := Insert[sensitiveModel](ctx, querier, "users", sensitiveModel{ID: 1, Email: "a@acme.com", Password: "secret"})
+				return err
+			},
+			expectedOp:    "insert",
+			expectedTable: "users",
+			expectedSQL:   `INSERT INTO "users" ("email", "id", "password", "token") VALUES ($1, $2, $3, $4)`,
+			expectedArgs:  []any{"a@acme.com", int64(1), "secret", nil},
+		},
+		{
+			name:      "select",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := Select[sensitiveModel](ctx, querier, "users", WithSelectFilters(Column("users", "id").Eq(1)))
+				return err
+			},
+			expectedOp:    "select",
+			expectedTable: "users",
+			expectedSQL:   `SELECT "users"."id", "users"."email", "users"."password", "users"."token" FROM "users" WHERE ("users"."id" = $1)`,
+			expectedArgs:  []any{int64(1)},
+		},
+		{
+			name:      "select_filter_on_sensitive_column",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := SelectOne[sensitiveModel](ctx, querier, "users", WithSelectFilters(Column("users", "password").Eq("hunter2")))
+				return err
+			},
+			expectedOp:    "select",
+			expectedTable: "users",
+			expectedSQL:   `SELECT "users"."id", "users"."email", "users"."password", "users"."token" FROM "users" WHERE ("users"."password" = $1) LIMIT $2`,
+			expectedArgs:  []any{redactedArg, int64(1)},
+		},
+		{
+			name:      "delete_in_and_between",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := Delete[sensitiveModel](ctx, querier, "users", WithDeleteFilters(
+					Column("users", "token").In("a", "b"),
+					Column("users", "id").Between(goqu.Range(1, 5)),
+					Column("users", "email").NotLike("%@acme.com"),
+				))
+				return err
+			},
+			expectedOp:    "delete",
+			expectedTable: "users",
+			expectedSQL: `DELETE FROM "users" WHERE (("users"."token" IN ($1, $2)) AND ("users"."id" BETWEEN $3 AND $4) AND ` +
+				`("users"."email" NOT LIKE $5))`,
+			expectedArgs: []any{redactedArg, redactedArg, int64(1), int64(5), "%@acme.com"},
+		},
+		{
+			name:      "unresolved_column",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := Select[sensitiveModel](ctx, querier, "users", WithSelectFilters(goqu.L(`lower("password") = ?`, "hunter2")))
+				return err
+			},
+			expectedOp:    "select",
+			expectedTable: "users",
+			expectedSQL:   `SELECT "users"."id", "users"."email", "users"."password", "users"."token" FROM "users" WHERE lower("password") = $1`,
+			expectedArgs:  []any{redactedArg},
+		},
+		{
+			name:      "select_ex_filter_on_sensitive_column",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := Select[sensitiveModel](ctx, querier, "users", WithSelectFilters(goqu.Ex{"password": "hunter2", "id": 1}))
+				return err
+			},
+			expectedOp:    "select",
+			expectedTable: "users",
+			expectedSQL:   `SELECT "users"."id", "users"."email", "users"."password", "users"."token" FROM "users" WHERE (("id" = $1) AND ("password" = $2))`,
+			expectedArgs:  []any{int64(1), redactedArg},
+		},
+		{
+			name:      "sub_query_redacts_all",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := Select[sensitiveModel](ctx, querier, "users", WithSelectFilters(
+					Column("users", "id").In(goqu.From("sessions").Select("user_id").Where(goqu.C("secret").Eq("hunter2")))))
+				return err
+			},
+			expectedOp:    "select",
+			expectedTable: "users",
+			expectedSQL: `SELECT "users"."id", "users"."email", "users"."password", "users"."token" FROM "users" ` +
+				`WHERE ("users"."id" IN ((SELECT "user_id" FROM "sessions" WHERE ("secret" = ?))))`,
+			expectedArgs: []any{redactedArg},
+		},
+		{
+			name:      "insert_not_prepared_redact_sensitive",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := Insert[sensitiveModel](ctx, querier, "users", sensitiveModel{ID: 1, Email: "a@acme.com", Password: "hunter2"},
+					WithInsertNotPrepared())
+				return err
+			},
+			expectedOp:    "insert",
+			expectedTable: "users",
+			expectedSQL:   `INSERT INTO "users" ("email", "id", "password", "token") VALUES ($1, $2, $3, $4)`,
+			expectedArgs:  []any{"a@acme.com", int64(1), redactedArg, nil},
+		},
+		{
+			name:      "insert_not_prepared_redact_all",
+			redaction: RedactAll,
+			run: func(querier Querier) error {
+				_, err := Insert[sensitiveModel](ctx, querier, "users", sensitiveModel{ID: 1, Email: "a@acme.com", Password: "hunter2"},
+					WithInsertNotPrepared())
+				return err
+			},
+			expectedOp:    "insert",
+			expectedTable: "users",
+			expectedSQL:   `INSERT INTO "users" ("email", "id", "password", "token") VALUES ($1, $2, $3, $4)`,
+			expectedArgs:  []any{redactedArg, redactedArg, redactedArg, redactedArg},
+		},
+		{
+			name:      "insert_not_prepared_redact_none",
+			redaction: RedactNone,
+			run: func(querier Querier) error {
+				_, err := Insert[sensitiveModel](ctx, querier, "users", sensitiveModel{ID: 1, Email: "a@acme.com", Password: "hunter2"},
+					WithInsertNotPrepared())
+				return err
+			},
+			expectedOp:    "insert",
+			expectedTable: "users",
+			expectedSQL:   `INSERT INTO "users" ("email", "id", "password", "token") VALUES ('a@acme.com', 1, 'hunter2', NULL)`,
+			expectedArgs:  []any{},
+		},
+		{
+			name:      "update_many_redact_sensitive",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				_, err := UpdateMany(ctx, querier, "users", []sensitiveModel{{ID: 1, Email: "a@acme.com", Password: "secret"}})
+				return err
+			},
+			expectedOp:    "update many",
+			expectedTable: "users",
+			expectedSQL: `UPDATE "users" SET "email"="goqux_values"."email","password"="goqux_values"."password","token"="goqux_values"."token" ` +
+				`FROM (VALUES ($1::bigint, $2::text, $3::text, $4::text)) AS "goqux_values" ("id", "email", "password", "token") ` +
+				`WHERE ("users"."id" = "goqux_values"."id")`,
+			expectedArgs: []any{int64(1), "a@acme.com", redactedArg, nil},
+		},
+		{
+			name:      "pagination_count",
+			redaction: RedactSensitive,
+			run: func(querier Querier) error {
+				p, err := SelectPagination[sensitiveModel](ctx, querier, "users", &PaginationOptions{PageSize: 10, CountTotal: true})
+				if err != nil {
+					return err
+				}
+				_, err = p.NextPage()
+				return err
+			},
+			expectedOp:    "count",
+			expectedTable: "users",
+			expectedSQL:   `SELECT COUNT(*) FROM (SELECT "users"."id", "users"."email", "users"."password", "users"."token" FROM "users") AS "goqux_count"`,
+			expectedArgs:  []any{},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetQueryErrorRedaction(tt.redaction)
+			defer SetQueryErrorRedaction(RedactSensitive)
+			driverErr := &pq.Error{Code: "23505", Table: "users", Constraint: "users_pkey"}
+			querier := newFakeSQLQuerier(t, &fakeDriver{queryErrs: []error{driverErr}})
+			err := tt.run(querier)
+			var queryErr *QueryError
+			require.ErrorAs(t, err, &queryErr)
+			assert.Equal(t, tt.expectedOp, queryErr.Op)
+			assert.Equal(t, tt.expectedTable, queryErr.Table)
+			assert.Equal(t, tt.expectedSQL, queryErr.SQL)
+			assert.Equal(t, tt.expectedArgs, queryErr.Args)
+			assert.ErrorIs(t, err, ErrUniqueViolation)
+			assert.ErrorIs(t, err, driverErr)
+		})
+	}
+}
+
+func TestQueryErrorMessage(t *testing.T) {
+	err := &QueryError{Op: "select", Table: "users", Err: errors.New("failed")}
+	assert.EqualError(t, err, "goqux: failed to select users: failed")
+	err.Table = ""
+	assert.EqualError(t, err, "goqux: failed to select: failed")
+}
+
+func TestRedactQueryConcurrently(t *testing.T) {
+	defer SetQueryErrorRedaction(RedactSensitive)
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			SetQueryErrorRedaction(Redaction(i % 3))
+			redactQuery(`SELECT * FROM "users" WHERE ("users"."id" = $1)`, []any{1}, nil)
+		}()
+	}
+	wg.Wait()
+}
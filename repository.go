@@ -0,0 +1,175 @@
+package goqux
+
+import (
+	"context"
+	"iter"
+
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// Repo is the interface implemented by Repository, use it to mock a repository in tests.
+type Repo[T any] interface {
+	Select(ctx context.Context, options ...SelectOption) ([]T, error)
+	SelectOne(ctx context.Context, options ...SelectOption) (T, error)
+	SelectByPK(ctx context.Context, key any, options ...SelectOption) (T, error)
+	SelectEach(ctx context.Context, fn func(T) error, options ...SelectOption) error
+	SelectSeq(ctx context.Context, options ...SelectOption) iter.Seq2[T, error]
+	SelectPagination(ctx context.Context, paginationOptions *PaginationOptions, options ...SelectOption) (*Paginator[T], error)
+	SelectPaginationFromCursor(ctx context.Context, cursor string, paginationOptions *PaginationOptions, options ...SelectOption) (*Paginator[T], error)
+	Insert(ctx context.Context, value any, options ...InsertOption) (*T, error)
+	InsertMany(ctx context.Context, values []any, options ...InsertOption) ([]T, error)
+	Update(ctx context.Context, value any, options ...UpdateOption) ([]T, error)
+	UpdateByPK(ctx context.Context, value any, options ...UpdateOption) ([]T, error)
+	Delete(ctx context.Context, options ...DeleteOption) ([]T, error)
+	DeleteByPK(ctx context.Context, key any, options ...DeleteOption) ([]T, error)
+}
+
+var _ Repo[struct{}] = (*Repository[struct{}])(nil)
+
+// RepositoryOption sets the default options of a Repository.
+type RepositoryOption func(c *repositoryConfig)
+
+type repositoryConfig struct {
+	dialect string
+	filters []exp.Expression
+	order   []exp.OrderedExpression
+}
+
+// WithRepositoryDialect sets the dialect of all the repository queries.
+func WithRepositoryDialect(dialect string) RepositoryOption {
+	return func(c *repositoryConfig) {
+		c.dialect = dialect
+	}
+}
+
+// WithRepositoryFilters adds filters to all the repository select, update and delete queries, i.e. to scope the repository
+// to a tenant or to rows that aren't soft deleted.
+func WithRepositoryFilters(filters ...exp.Expression) RepositoryOption {
+	return func(c *repositoryConfig) {
+		c.filters = append(c.filters, filters...)
+	}
+}
+
+// WithRepositoryOrder sets the default order of the repository select queries, an order or keyset given to a query replaces it.
+func WithRepositoryOrder(order ...exp.OrderedExpression) RepositoryOption {
+	return func(c *repositoryConfig) {
+		c.order = append(c.order, order...)
+	}
+}
+
+// Repository runs the goqux queries of T on a single table with the same querier and default options,
+// the options given to each method are applied after the default options.
+type Repository[T any] struct {
+	querier   Querier
+	tableName string
+	config    repositoryConfig
+}
+
+// NewRepository returns a repository of T for the given table.
+func NewRepository[T any](querier Querier, tableName string, options ...RepositoryOption) *Repository[T] {
+	r := &Repository[T]{querier: querier, tableName: tableName}
+	for _, o := range options {
+		o(&r.config)
+	}
+	return r
+}
+
+// TableName returns the table name of the repository.
+func (r *Repository[T]) TableName() string {
+	return r.tableName
+}
+
+func (r *Repository[T]) selectOptions(options []SelectOption) []SelectOption {
+	defaults := make([]SelectOption, 0, len(options)+3)
+	if r.config.dialect != "" {
+		defaults = append(defaults, WithSelectDialect(r.config.dialect))
+	}
+	if len(r.config.filters) > 0 {
+		defaults = append(defaults, WithSelectFilters(r.config.filters...))
+	}
+	if len(r.config.order) > 0 {
+		defaults = append(defaults, WithSelectOrder(r.config.order...))
+	}
+	return append(defaults, options...)
+}
+
+func (r *Repository[T]) insertOptions(options []InsertOption) []InsertOption {
+	if r.config.dialect == "" {
+		return options
+	}
+	return append([]InsertOption{WithInsertDialect(r.config.dialect)}, options...)
+}
+
+func (r *Repository[T]) updateOptions(options []UpdateOption) []UpdateOption {
+	defaults := make([]UpdateOption, 0, len(options)+2)
+	if r.config.dialect != "" {
+		defaults = append(defaults, WithUpdateDialect(r.config.dialect))
+	}
+	if len(r.config.filters) > 0 {
+		defaults = append(defaults, WithUpdateFilters(r.config.filters...))
+	}
+	return append(defaults, options...)
+}
+
+func (r *Repository[T]) deleteOptions(options []DeleteOption) []DeleteOption {
+	defaults := make([]DeleteOption, 0, len(options)+2)
+	if r.config.dialect != "" {
+		defaults = append(defaults, WithDeleteDialect(r.config.dialect))
+	}
+	if len(r.config.filters) > 0 {
+		defaults = append(defaults, WithDeleteFilters(r.config.filters...))
+	}
+	return append(defaults, options...)
+}
+
+func (r *Repository[T]) Select(ctx context.Context, options ...SelectOption) ([]T, error) {
+	return Select[T](ctx, r.querier, r.tableName, r.selectOptions(options)...)
+}
+
+func (r *Repository[T]) SelectOne(ctx context.Context, options ...SelectOption) (T, error) {
+	return SelectOne[T](ctx, r.querier, r.tableName, r.selectOptions(options)...)
+}
+
+func (r *Repository[T]) SelectByPK(ctx context.Context, key any, options ...SelectOption) (T, error) {
+	return SelectByPK[T](ctx, r.querier, r.tableName, key, r.selectOptions(options)...)
+}
+
+func (r *Repository[T]) SelectEach(ctx context.Context, fn func(T) error, options ...SelectOption) error {
+	return SelectEach(ctx, r.querier, r.tableName, fn, r.selectOptions(options)...)
+}
+
+func (r *Repository[T]) SelectSeq(ctx context.Context, options ...SelectOption) iter.Seq2[T, error] {
+	return SelectSeq[T](ctx, r.querier, r.tableName, r.selectOptions(options)...)
+}
+
+func (r *Repository[T]) SelectPagination(ctx context.Context, paginationOptions *PaginationOptions, options ...SelectOption) (*Paginator[T], error) {
+	return SelectPagination[T](ctx, r.querier, r.tableName, paginationOptions, r.selectOptions(options)...)
+}
+
+func (r *Repository[T]) SelectPaginationFromCursor(ctx context.Context, cursor string, paginationOptions *PaginationOptions, options ...SelectOption) (*Paginator[T], error) {
+	return SelectPaginationFromCursor[T](ctx, r.querier, r.tableName, cursor, paginationOptions, r.selectOptions(options)...)
+}
+
+func (r *Repository[T]) Insert(ctx context.Context, value any, options ...InsertOption) (*T, error) {
+	return Insert[T](ctx, r.querier, r.tableName, value, r.insertOptions(options)...)
+}
+
+func (r *Repository[T]) InsertMany(ctx context.Context, values []any, options ...InsertOption) ([]T, error) {
+	return InsertMany[T](ctx, r.querier, r.tableName, values, r.insertOptions(options)...)
+}
+
+func (r *Repository[T]) Update(ctx context.Context, value any, options ...UpdateOption) ([]T, error) {
+	return Update[T](ctx, r.querier, r.tableName, value, r.updateOptions(options)...)
+}
+
+func (r *Repository[T]) UpdateByPK(ctx context.Context, value any, options ...UpdateOption) ([]T, error) {
+	return UpdateByPK[T](ctx, r.querier, r.tableName, value, r.updateOptions(options)...)
+}
+
+func (r *Repository[T]) Delete(ctx context.Context, options ...DeleteOption) ([]T, error) {
+	return Delete[T](ctx, r.querier, r.tableName, r.deleteOptions(options)...)
+}
+
+func (r *Repository[T]) DeleteByPK(ctx context.Context, key any, options ...DeleteOption) ([]T, error) {
+	return DeleteByPK[T](ctx, r.querier, r.tableName, key, r.deleteOptions(options)...)
+}
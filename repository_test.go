@@ -0,0 +1,123 @@
+package goqux_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jackc/pgx/v5"
+	"github.com/roneli/goqux"
+	"github.com/stretchr/testify/assert"
+)
+
+var errRecorded = errors.New("recorded")
+
+// recordingQuerier records the queries it receives without running them.
+type recordingQuerier struct {
+	queries []string
+	args    [][]any
+}
+
+func (q *recordingQuerier) Query(_ context.Context, sql string, args ...any) (pgx.Rows, error) {
+	q.queries = append(q.queries, sql)
+	q.args = append(q.args, args)
+	return nil, errRecorded
+}
+
+type repositoryModel struct {
+	ID       int64 `goqux:"pk"`
+	TenantID int64
+	Name     string
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	tableTests := []struct {
+		name          string
+		run           func(r goqux.Repo[repositoryModel]) error
+		expectedQuery string
+		expectedArgs  []any
+	}{
+		{
+			name: "select",
+			run: func(r goqux.Repo[repositoryModel]) error {
+				_, err := r.Select(ctx, goqux.WithSelectLimit(10))
+				return err
+			},
+			expectedQuery: `SELECT "models"."id", "models"."tenant_id", "models"."name" FROM "models" WHERE ("models"."tenant_id" = $1) ORDER BY "models"."name" ASC LIMIT $2`,
+			expectedArgs:  []any{int64(1), int64(10)},
+		},
+		{
+			name: "select_order_override",
+			run: func(r goqux.Repo[repositoryModel]) error {
+				_, err := r.SelectOne(ctx, goqux.WithSelectOrder(goqu.C("id").Desc()))
+				return err
+			},
+			expectedQuery: `SELECT "models"."id", "models"."tenant_id", "models"."name" FROM "models" WHERE ("models"."tenant_id" = $1) ORDER BY "id" DESC LIMIT $2`,
+			expectedArgs:  []any{int64(1), int64(1)},
+		},
+		{
+			name: "select_by_pk",
+			run: func(r goqux.Repo[repositoryModel]) error {
+				_, err := r.SelectByPK(ctx, 5)
+				return err
+			},
+			expectedQuery: `SELECT "models"."id", "models"."tenant_id", "models"."name" FROM "models" WHERE (("models"."tenant_id" = $1) AND ("models"."id" = $2)) ORDER BY "models"."name" ASC`,
+			expectedArgs:  []any{int64(1), int64(5)},
+		},
+		{
+			name: "keyset_pagination",
+			run: func(r goqux.Repo[repositoryModel]) error {
+				p, err := r.SelectPagination(ctx, &goqux.PaginationOptions{PageSize: 5, KeySet: []string{"ID"}})
+				if err != nil {
+					return err
+				}
+				_, err = p.NextPage()
+				return err
+			},
+			expectedQuery: `SELECT "models"."id", "models"."tenant_id", "models"."name" FROM "models" WHERE ("models"."tenant_id" = $1) ORDER BY "models"."id" ASC LIMIT $2`,
+			expectedArgs:  []any{int64(1), int64(5)},
+		},
+		{
+			name: "insert",
+			run: func(r goqux.Repo[repositoryModel]) error {
+				_, err := r.Insert(ctx, repositoryModel{ID: 1, TenantID: 1, Name: "name"})
+				return err
+			},
+			expectedQuery: `INSERT INTO "models" ("id", "name", "tenant_id") VALUES ($1, $2, $3)`,
+			expectedArgs:  []any{int64(1), "name", int64(1)},
+		},
+		{
+			name: "update_by_pk",
+			run: func(r goqux.Repo[repositoryModel]) error {
+				_, err := r.UpdateByPK(ctx, repositoryModel{ID: 5, Name: "name"})
+				return err
+			},
+			expectedQuery: `UPDATE "models" SET "name"=$1 WHERE (("models"."id" = $2) AND ("models"."tenant_id" = $3))`,
+			expectedArgs:  []any{"name", int64(5), int64(1)},
+		},
+		{
+			name: "delete",
+			run: func(r goqux.Repo[repositoryModel]) error {
+				_, err := r.Delete(ctx, goqux.WithDeleteFilters(goqux.Column("models", "name").Eq("name")))
+				return err
+			},
+			expectedQuery: `DELETE FROM "models" WHERE (("models"."tenant_id" = $1) AND ("models"."name" = $2))`,
+			expectedArgs:  []any{int64(1), "name"},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			querier := &recordingQuerier{}
+			r := goqux.NewRepository[repositoryModel](querier, "models",
+				goqux.WithRepositoryDialect("postgres"),
+				goqux.WithRepositoryFilters(goqux.Column("models", "tenant_id").Eq(1)),
+				goqux.WithRepositoryOrder(goqux.Column("models", "name").Asc()),
+			)
+			assert.ErrorIs(t, tt.run(r), errRecorded)
+			assert.Equal(t, []string{tt.expectedQuery}, querier.queries)
+			assert.Equal(t, [][]any{tt.expectedArgs}, querier.args)
+		})
+	}
+}
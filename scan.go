@@ -0,0 +1,281 @@
+package goqux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// ParallelScanOptions configures ParallelScan.
+type ParallelScanOptions struct {
+	// Key is the struct field of the integer or timestamp column the table is split on.
+	Key string
+	// KeySet is the keyset each partition is paginated with, it must end with unique columns so no row is skipped
+	// between pages. It defaults to Key followed by the goqux:"pk" fields of T, and is required if T has none.
+	KeySet []string
+	// Partitions is the number of key ranges scanned concurrently, defaults to 4.
+	Partitions uint
+	// PageSize is the page size of each partition, defaults to 1000.
+	PageSize uint
+	// Progress is called after each page of a partition and once the partition is done, it's called concurrently
+	// from all partitions.
+	Progress func(ScanProgress)
+}
+
+// ScanProgress reports the progress of a single ParallelScan partition.
+type ScanProgress struct {
+	// Partition is the index of the partition, from 0 to Partitions-1.
+	Partition int
+	// From and To are the key range of the partition, To is exclusive except for the last partition.
+	From any
+	To   any
+	// Pages and Rows are the number of pages and rows scanned so far.
+	Pages uint
+	Rows  uint64
+	// Done is set once all rows of the partition were scanned.
+	Done bool
+}
+
+// scanRange is the key range of a single partition, the last range includes its upper bound.
+type scanRange struct {
+	from any
+	to   any
+	last bool
+}
+
+func (r scanRange) filter(column string) SelectOption {
+	return func(table exp.IdentifierExpression, s *goqu.SelectDataset) *goqu.SelectDataset {
+		col := table.Col(column)
+		if r.last {
+			return s.Where(col.Gte(r.from), col.Lte(r.to))
+		}
+		return s.Where(col.Gte(r.from), col.Lt(r.to))
+	}
+}
+
+// ParallelScan splits the rows matching the select options into key ranges between the MIN and MAX of the Key column,
+// and scans the ranges concurrently, each with its own keyset paginator. fn is called concurrently from all partitions,
+// and the querier must be safe for concurrent use (i.e. *pgxpool.Pool). Rows with a key outside the range discovered
+// when the scan starts are not scanned.
+//
+// The scan stops on the first error, cancelling the other partitions, return ErrStopIteration from fn to stop without failing.
+func ParallelScan[T any](ctx context.Context, querier Querier, tableName string, scanOptions *ParallelScanOptions, fn func(T) error, options ...SelectOption) error {
+	if scanOptions == nil || scanOptions.Key == "" {
+		return fmt.Errorf("goqux: key is required for parallel scan")
+	}
+	partitions := scanOptions.Partitions
+	if partitions == 0 {
+		partitions = 4
+	}
+	pageSize := scanOptions.PageSize
+	if pageSize == 0 {
+		pageSize = 1000
+	}
+	keyset, err := parallelScanKeySet[T](scanOptions)
+	if err != nil {
+		return err
+	}
+	key, err := scanKeyField[T](scanOptions.Key)
+	if err != nil {
+		return err
+	}
+	lo, hi, err := scanKeyBounds[T](ctx, querier, tableName, key, options...)
+	if err != nil {
+		return err
+	}
+	if !lo.IsValid() {
+		// no rows match the filters
+		return nil
+	}
+	ranges, err := splitScanRange(lo, hi, partitions)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i, r := range ranges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := scanPartition(ctx, querier, tableName, scanOptions, i, r, key.column, keyset, pageSize, fn, options)
+			if err == nil {
+				return
+			}
+			once.Do(func() {
+				if !errors.Is(err, ErrStopIteration) {
+					firstErr = fmt.Errorf("goqux: failed to scan partition %d: %w", i, err)
+				}
+				cancel()
+			})
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// parallelScanKeySet returns the keyset of the partitions, Key alone is rarely unique, i.e. timestamps, so it's followed
+// by the primary key fields.
+func parallelScanKeySet[T any](scanOptions *ParallelScanOptions) ([]string, error) {
+	if len(scanOptions.KeySet) > 0 {
+		return scanOptions.KeySet, nil
+	}
+	t := reflect.TypeFor[T]()
+	metadata, err := getStructMetadata(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata.pk) == 0 {
+		return nil, fmt.Errorf("%w: parallel scan of %s requires goqux:\"pk\" fields or a KeySet ending with unique columns", ErrMissingPrimaryKey, t)
+	}
+	keyset := []string{scanOptions.Key}
+	for _, f := range metadata.pk {
+		if f.name != scanOptions.Key {
+			keyset = append(keyset, f.name)
+		}
+	}
+	return keyset, nil
+}
+
+func scanPartition[T any](ctx context.Context, querier Querier, tableName string, scanOptions *ParallelScanOptions, partition int, r scanRange,
+	column string, keyset []string, pageSize uint, fn func(T) error, options []SelectOption) error {
+	p, err := SelectPagination[T](ctx, querier, tableName, &PaginationOptions{PageSize: pageSize, KeySet: keyset},
+		append(options[:len(options):len(options)], r.filter(column))...)
+	if err != nil {
+		return err
+	}
+	progress := ScanProgress{Partition: partition, From: r.from, To: r.to}
+	for page, err := range p.Pages() {
+		if err != nil {
+			return err
+		}
+		for _, row := range page {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		progress.Pages++
+		progress.Rows += uint64(len(page))
+		if scanOptions.Progress != nil && p.HasMorePages() {
+			scanOptions.Progress(progress)
+		}
+	}
+	progress.Done = true
+	if scanOptions.Progress != nil {
+		scanOptions.Progress(progress)
+	}
+	return nil
+}
+
+// scanKeyField returns the metadata of the Key field of T, its column honours the db tag.
+func scanKeyField[T any](key string) (*fieldMetadata, error) {
+	metadata, err := getStructMetadata(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+	field, ok := metadata.field(key)
+	if !ok {
+		return nil, fmt.Errorf("goqux: key field %s not found", key)
+	}
+	return field, nil
+}
+
+// scanKeyBounds returns the MIN and MAX of the key column over the filtered rows, as values of the key field type,
+// both are invalid if there are no rows.
+func scanKeyBounds[T any](ctx context.Context, querier Querier, tableName string, key *fieldMetadata, options ...SelectOption) (reflect.Value, reflect.Value, error) {
+	keyType := key.typ
+	if keyType.Kind() == reflect.Pointer {
+		keyType = keyType.Elem()
+	}
+	col := tableIdentifier(tableName).Col(key.column)
+	sd := buildSelectDataset(tableName, new(T), options...).
+		ClearSelect().ClearOrder().ClearLimit().ClearOffset().
+		Select(goqu.MIN(col), goqu.MAX(col))
+	query, args, err := sd.ToSQL()
+	if err != nil {
+		return reflect.Value{}, reflect.Value{}, fmt.Errorf("goqux: failed to build key bounds query: %w", err)
+	}
+	// scan into pointers so an empty result set, where MIN and MAX are NULL, can be detected
+	lo, hi := reflect.New(reflect.PointerTo(keyType)), reflect.New(reflect.PointerTo(keyType))
+	rows, err := querier.Query(ctx, query, args...)
+	if err != nil {
+		return reflect.Value{}, reflect.Value{}, newQueryError("select key bounds", tableName, query, args, redactedSelect(sd, reflect.TypeFor[T]()), err)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(lo.Interface(), hi.Interface()); err != nil {
+			return reflect.Value{}, reflect.Value{}, newQueryError("scan", tableName, query, args, redactedSelect(sd, reflect.TypeFor[T]()), err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return reflect.Value{}, reflect.Value{}, newQueryError("select key bounds", tableName, query, args, redactedSelect(sd, reflect.TypeFor[T]()), err)
+	}
+	if lo.Elem().IsNil() || hi.Elem().IsNil() {
+		return reflect.Value{}, reflect.Value{}, nil
+	}
+	return lo.Elem().Elem(), hi.Elem().Elem(), nil
+}
+
+// splitScanRange splits [min, max] into up to partitions ranges of about equal size, integer ranges with fewer values
+// than partitions get a single value per range.
+func splitScanRange(min, max reflect.Value, partitions uint) ([]scanRange, error) {
+	var bounds []any
+	switch {
+	case min.Type() == timeType:
+		lo, hi := min.Interface().(time.Time), max.Interface().(time.Time)
+		step := hi.Sub(lo) / time.Duration(partitions)
+		if step <= 0 {
+			partitions, step = 1, 0
+		}
+		for i := range partitions {
+			bounds = append(bounds, lo.Add(time.Duration(i)*step))
+		}
+	case min.CanInt(), min.CanUint():
+		// work on the distance from min as uint64, so the full int64 range doesn't overflow
+		var lo, span uint64
+		if min.CanInt() {
+			lo, span = uint64(min.Int()), uint64(max.Int())-uint64(min.Int())
+		} else {
+			lo, span = min.Uint(), max.Uint()-min.Uint()
+		}
+		n, step, rem := uint64(partitions), span/uint64(partitions), span%uint64(partitions)
+		if span < n {
+			n, step, rem = span+1, 1, 0
+		}
+		for i := range n {
+			v := reflect.New(min.Type()).Elem()
+			if min.CanInt() {
+				v.SetInt(int64(lo + i*step + i*rem/n))
+			} else {
+				v.SetUint(lo + i*step + i*rem/n)
+			}
+			bounds = append(bounds, v.Interface())
+		}
+	default:
+		return nil, fmt.Errorf("goqux: unsupported parallel scan key type %s", min.Type())
+	}
+	ranges := make([]scanRange, len(bounds))
+	for i, from := range bounds {
+		ranges[i] = scanRange{from: from}
+		if i < len(bounds)-1 {
+			ranges[i].to = bounds[i+1]
+		} else {
+			ranges[i].to, ranges[i].last = max.Interface(), true
+		}
+	}
+	return ranges, nil
+}
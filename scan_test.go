@@ -0,0 +1,167 @@
+package goqux
+
+import (
+	"context"
+	"database/sql/driver"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitScanRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tableTests := []struct {
+		name       string
+		min        any
+		max        any
+		partitions uint
+		expected   []scanRange
+	}{
+		{
+			name:       "even_split",
+			min:        int64(1),
+			max:        int64(12),
+			partitions: 4,
+			expected: []scanRange{
+				{from: int64(1), to: int64(3)},
+				{from: int64(3), to: int64(6)},
+				{from: int64(6), to: int64(9)},
+				{from: int64(9), to: int64(12), last: true},
+			},
+		},
+		{
+			name:       "fewer_values_than_partitions",
+			min:        int32(5),
+			max:        int32(6),
+			partitions: 4,
+			expected: []scanRange{
+				{from: int32(5), to: int32(6)},
+				{from: int32(6), to: int32(6), last: true},
+			},
+		},
+		{
+			name:       "single_value",
+			min:        uint(7),
+			max:        uint(7),
+			partitions: 3,
+			expected:   []scanRange{{from: uint(7), to: uint(7), last: true}},
+		},
+		{
+			name:       "full_int64_range",
+			min:        int64(math.MinInt64),
+			max:        int64(math.MaxInt64),
+			partitions: 2,
+			expected: []scanRange{
+				{from: int64(math.MinInt64), to: int64(-1)},
+				{from: int64(-1), to: int64(math.MaxInt64), last: true},
+			},
+		},
+		{
+			name:       "time",
+			min:        start,
+			max:        start.Add(4 * time.Hour),
+			partitions: 2,
+			expected: []scanRange{
+				{from: start, to: start.Add(2 * time.Hour)},
+				{from: start.Add(2 * time.Hour), to: start.Add(4 * time.Hour), last: true},
+			},
+		},
+		{
+			name:       "same_time",
+			min:        start,
+			max:        start,
+			partitions: 2,
+			expected:   []scanRange{{from: start, to: start, last: true}},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, err := splitScanRange(reflect.ValueOf(tt.min), reflect.ValueOf(tt.max), tt.partitions)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ranges)
+		})
+	}
+}
+
+func TestParallelScanKeySet(t *testing.T) {
+	type event struct {
+		ID        int64 `goqux:"pk"`
+		CreatedAt time.Time
+	}
+	type tenantEvent struct {
+		TenantID  int64 `goqux:"pk"`
+		ID        int64 `goqux:"pk"`
+		CreatedAt time.Time
+	}
+	type noPKEvent struct {
+		ID        int64
+		CreatedAt time.Time
+	}
+	keyset, err := parallelScanKeySet[event](&ParallelScanOptions{Key: "ID"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ID"}, keyset)
+	keyset, err = parallelScanKeySet[event](&ParallelScanOptions{Key: "CreatedAt"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CreatedAt", "ID"}, keyset)
+	keyset, err = parallelScanKeySet[tenantEvent](&ParallelScanOptions{Key: "ID"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ID", "TenantID"}, keyset)
+	keyset, err = parallelScanKeySet[noPKEvent](&ParallelScanOptions{Key: "CreatedAt", KeySet: []string{"CreatedAt", "ID"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CreatedAt", "ID"}, keyset)
+	_, err = parallelScanKeySet[noPKEvent](&ParallelScanOptions{Key: "CreatedAt"})
+	assert.ErrorIs(t, err, ErrMissingPrimaryKey)
+}
+
+func TestSplitScanRangeUnsupportedType(t *testing.T) {
+	_, err := splitScanRange(reflect.ValueOf("a"), reflect.ValueOf("z"), 2)
+	assert.Error(t, err)
+}
+
+func TestScanKeyBoundsScanError(t *testing.T) {
+	type event struct {
+		ID int64 `goqux:"pk"`
+	}
+	d := &fakeDriver{
+		columns: []string{"min", "max"},
+		rows:    [][]driver.Value{{"a", "b"}},
+	}
+	key, err := scanKeyField[event]("ID")
+	require.NoError(t, err)
+	_, _, err = scanKeyBounds[event](context.Background(), newFakeSQLQuerier(t, d), "events", key)
+	var queryErr *QueryError
+	require.ErrorAs(t, err, &queryErr)
+	assert.Equal(t, "scan", queryErr.Op)
+	assert.Equal(t, "events", queryErr.Table)
+}
+
+func TestScanKeyColumnFromDBTag(t *testing.T) {
+	type event struct {
+		ID        int64     `goqux:"pk"`
+		CreatedAt time.Time `db:"created"`
+	}
+	key, err := scanKeyField[event]("CreatedAt")
+	require.NoError(t, err)
+	assert.Equal(t, "created", key.column)
+
+	d := &fakeDriver{
+		columns: []string{"min", "max"},
+		rows:    [][]driver.Value{{nil, nil}},
+	}
+	lo, _, err := scanKeyBounds[event](context.Background(), newFakeSQLQuerier(t, d), "events", key)
+	require.NoError(t, err)
+	assert.False(t, lo.IsValid())
+	require.Len(t, d.queries, 1)
+	assert.Equal(t, `SELECT MIN("events"."created"), MAX("events"."created") FROM "events"`, d.queries[0])
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	filtered := scanRange{from: at, to: at, last: true}.filter(key.column)(goqu.T("events"), goqu.Dialect(defaultDialect).From("events"))
+	query, _, err := filtered.ToSQL()
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "events" WHERE (("events"."created" >= $1) AND ("events"."created" <= $2))`, query)
+}
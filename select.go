@@ -32,19 +32,24 @@ func WithSelectOffset(offset uint) SelectOption {
 	}
 }
 
+// WithKeySet orders the query by the given keyset columns, and if values are given, filters only rows that come after them,
+// multi-column keysets are compared lexicographically so rows sharing the leading column values aren't skipped.
 func WithKeySet(columns []string, values []any) SelectOption {
+	return WithKeySetColumns(keySetFromNames(columns), values)
+}
+
+// WithKeySetColumns is like WithKeySet but allows setting the direction and NULL ordering of each keyset column.
+func WithKeySetColumns(keyset []KeySetColumn, values []any) SelectOption {
 	return func(table exp.IdentifierExpression, s *goqu.SelectDataset) *goqu.SelectDataset {
-		s.ClearOrder()
+		cols := make([]exp.IdentifierExpression, len(keyset))
+		for i, c := range keyset {
+			cols[i] = table.Col(strcase.ToSnake(c.Name))
+		}
+		s = s.Order(keySetOrder(keyset, cols)...)
 		if values == nil {
-			for _, c := range columns {
-				s = s.OrderAppend(table.Col(strcase.ToSnake(c)).Asc())
-			}
 			return s
 		}
-		for i, c := range columns {
-			s = s.Where(table.Col(strcase.ToSnake(c)).Gt(values[i]))
-			s = s.OrderAppend(table.Col(strcase.ToSnake(c)).Asc())
-		}
+		s = s.Where(keySetPredicate(s.Dialect().Dialect(), keyset, cols, values))
 		// Make sure to clear offset with KeySet pagination
 		return s.ClearOffset()
 	}
@@ -73,10 +78,14 @@ type JoinOp struct {
 func WithInnerJoinSelection[T any](op ...JoinOp) SelectOption {
 	return func(_ exp.IdentifierExpression, s *goqu.SelectDataset) *goqu.SelectDataset {
 		for _, j := range op {
-			s = s.InnerJoin(goqu.T(j.Table), j.On)
+			s = s.InnerJoin(tableIdentifier(j.Table), j.On)
+		}
+		selection, err := getSelectionFieldsFromSelectionStruct(new(T))
+		if err != nil {
+			return s.SetError(err)
 		}
-		selectFields := make([]any, 0)
-		for _, c := range getSelectionFieldsFromSelectionStruct(new(T)) {
+		selectFields := make([]any, 0, len(selection))
+		for _, c := range selection {
 			selectFields = append(selectFields, c)
 		}
 		return s.Select(selectFields...)
@@ -89,10 +98,14 @@ func WithInnerJoinSelection[T any](op ...JoinOp) SelectOption {
 func WithLeftJoinSelection[T any](op ...JoinOp) SelectOption {
 	return func(_ exp.IdentifierExpression, s *goqu.SelectDataset) *goqu.SelectDataset {
 		for _, j := range op {
-			s = s.LeftJoin(goqu.T(j.Table), j.On)
+			s = s.LeftJoin(tableIdentifier(j.Table), j.On)
 		}
-		selectFields := make([]any, 0)
-		for _, c := range getSelectionFieldsFromSelectionStruct(new(T)) {
+		selection, err := getSelectionFieldsFromSelectionStruct(new(T))
+		if err != nil {
+			return s.SetError(err)
+		}
+		selectFields := make([]any, 0, len(selection))
+		for _, c := range selection {
 			selectFields = append(selectFields, c)
 		}
 		return s.Select(selectFields...)
@@ -100,14 +113,48 @@ func WithLeftJoinSelection[T any](op ...JoinOp) SelectOption {
 }
 
 func BuildSelect[T any](tableName string, dst T, options ...SelectOption) (string, []any, error) {
-	table := goqu.T(tableName)
-	structCols := make([]any, 0)
-	for _, c := range getColumnsFromStruct(table, dst, skipSelect) {
+	return buildSelectDataset(tableName, dst, options...).ToSQL()
+}
+
+// BuildSelectByPK builds a select query for the row of T with the given primary key, key is either a struct with the
+// goqux:"pk" fields set, or the value of the single primary key of T.
+func BuildSelectByPK[T any](tableName string, key any, options ...SelectOption) (string, []any, error) {
+	sd, err := buildSelectByPKDataset[T](tableName, key, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	return sd.ToSQL()
+}
+
+func buildSelectByPKDataset[T any](tableName string, key any, options ...SelectOption) (*goqu.SelectDataset, error) {
+	filters, err := primaryKeyFilters[T](tableIdentifier(tableName), key)
+	if err != nil {
+		return nil, err
+	}
+	return buildSelectDataset(tableName, new(T), append(options, WithSelectFilters(filters...))...), nil
+}
+
+func buildSelectDataset[T any](tableName string, dst T, options ...SelectOption) *goqu.SelectDataset {
+	table := tableIdentifier(tableName)
+	cols, err := getColumnsFromStruct(table, dst, skipSelect)
+	if err != nil {
+		return goqu.Dialect(defaultDialect).From(table).SetError(err)
+	}
+	structCols := make([]any, 0, len(cols))
+	for _, c := range cols {
 		structCols = append(structCols, c)
 	}
 	selectQuery := goqu.Dialect(defaultDialect).Select(structCols...).From(table)
 	for _, o := range options {
 		selectQuery = o(table, selectQuery)
 	}
-	return selectQuery.ToSQL()
+	return selectQuery
+}
+
+// buildCountQuery returns a query counting the rows of the given dataset, ignoring its order, limit and offset.
+func buildCountQuery(sd *goqu.SelectDataset) (string, []any, error) {
+	return goqu.Dialect(sd.Dialect().Dialect()).
+		From(sd.ClearOrder().ClearLimit().ClearOffset().As("goqux_count")).
+		Select(goqu.COUNT(goqu.Star())).
+		ToSQL()
 }
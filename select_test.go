@@ -1,6 +1,7 @@
 package goqux_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/doug-martin/goqu/v9"
@@ -35,6 +36,74 @@ type Table2 struct {
 	StringField string `db:"cool_field"`
 }
 
+type pkModel struct {
+	ID   int64 `goqux:"pk"`
+	Name string
+}
+
+type compositePKModel struct {
+	TenantID int64 `db:"tenant" goqux:"pk"`
+	ID       int64 `goqux:"pk"`
+	Name     string
+}
+
+func TestBuildSelectByPK(t *testing.T) {
+	tableTests := []struct {
+		name          string
+		build         func() (string, []any, error)
+		expectedQuery string
+		expectedArgs  []interface{}
+		expectedError error
+	}{
+		{
+			name:          "select_by_value",
+			build:         func() (string, []any, error) { return goqux.BuildSelectByPK[pkModel]("pk_models", 5) },
+			expectedQuery: `SELECT "pk_models"."id", "pk_models"."name" FROM "pk_models" WHERE ("pk_models"."id" = $1)`,
+			expectedArgs:  []interface{}{int64(5)},
+		},
+		{
+			name:          "select_by_struct",
+			build:         func() (string, []any, error) { return goqux.BuildSelectByPK[pkModel]("pk_models", &pkModel{ID: 5}) },
+			expectedQuery: `SELECT "pk_models"."id", "pk_models"."name" FROM "pk_models" WHERE ("pk_models"."id" = $1)`,
+			expectedArgs:  []interface{}{int64(5)},
+		},
+		{
+			name: "select_by_composite_key",
+			build: func() (string, []any, error) {
+				return goqux.BuildSelectByPK[compositePKModel]("pk_models", compositePKModel{TenantID: 1, ID: 5})
+			},
+			expectedQuery: `SELECT "pk_models"."tenant", "pk_models"."id", "pk_models"."name" FROM "pk_models" WHERE (("pk_models"."tenant" = $1) AND ("pk_models"."id" = $2))`,
+			expectedArgs:  []interface{}{int64(1), int64(5)},
+		},
+		{
+			name:          "select_by_value_with_composite_key",
+			build:         func() (string, []any, error) { return goqux.BuildSelectByPK[compositePKModel]("pk_models", 5) },
+			expectedError: errors.New("goqux: goqux_test.compositePKModel has a composite primary key, the key must be a struct"),
+		},
+		{
+			name:          "select_without_primary_key",
+			build:         func() (string, []any, error) { return goqux.BuildSelectByPK[selectModel]("select_models", 5) },
+			expectedError: goqux.ErrMissingPrimaryKey,
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := tt.build()
+			if tt.expectedError != nil {
+				if errors.Is(tt.expectedError, goqux.ErrMissingPrimaryKey) {
+					assert.ErrorIs(t, err, tt.expectedError)
+				} else {
+					assert.EqualError(t, err, tt.expectedError.Error())
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
 func TestBuildSelect(t *testing.T) {
 	tableTests := []struct {
 		name          string
@@ -86,6 +155,69 @@ func TestBuildSelect(t *testing.T) {
 			expectedQuery: `SELECT "select_models"."int_field" FROM "select_models" ORDER BY "select_models"."int_field" DESC`,
 			expectedArgs:  []interface{}{},
 		},
+		{
+			name:          "select_with_keyset_first_page",
+			dst:           selectModel{},
+			options:       []goqux.SelectOption{goqux.WithKeySet([]string{"IntField"}, nil)},
+			expectedQuery: `SELECT "select_models"."int_field" FROM "select_models" ORDER BY "select_models"."int_field" ASC`,
+			expectedArgs:  []interface{}{},
+		},
+		{
+			name:          "select_with_keyset",
+			dst:           selectModel{},
+			options:       []goqux.SelectOption{goqux.WithKeySet([]string{"IntField"}, []any{1})},
+			expectedQuery: `SELECT "select_models"."int_field" FROM "select_models" WHERE ("select_models"."int_field" > $1) ORDER BY "select_models"."int_field" ASC`,
+			expectedArgs:  []interface{}{int64(1)},
+		},
+		{
+			name:          "select_with_multi_column_keyset",
+			dst:           selectModel{},
+			options:       []goqux.SelectOption{goqux.WithKeySet([]string{"CreatedAt", "ID"}, []any{"2024-01-01", 5})},
+			expectedQuery: `SELECT "select_models"."int_field" FROM "select_models" WHERE ("select_models"."created_at", "select_models"."id") > ($1, $2) ORDER BY "select_models"."created_at" ASC, "select_models"."id" ASC`,
+			expectedArgs:  []interface{}{"2024-01-01", int64(5)},
+		},
+		{
+			name:          "select_with_multi_column_keyset_without_row_comparison",
+			dst:           selectModel{},
+			options:       []goqux.SelectOption{goqux.WithSelectDialect("default"), goqux.WithKeySet([]string{"CreatedAt", "ID"}, []any{"2024-01-01", 5})},
+			expectedQuery: `SELECT "select_models"."int_field" FROM "select_models" WHERE (("select_models"."created_at" > ?) OR (("select_models"."created_at" = ?) AND ("select_models"."id" > ?))) ORDER BY "select_models"."created_at" ASC, "select_models"."id" ASC`,
+			expectedArgs:  []interface{}{"2024-01-01", "2024-01-01", int64(5)},
+		},
+		{
+			name:          "select_with_desc_keyset",
+			dst:           selectModel{},
+			options:       []goqux.SelectOption{goqux.WithKeySetColumns([]goqux.KeySetColumn{goqux.KeySetDesc("CreatedAt"), goqux.KeySetDesc("ID")}, []any{"2024-01-01", 5})},
+			expectedQuery: `SELECT "select_models"."int_field" FROM "select_models" WHERE ("select_models"."created_at", "select_models"."id") < ($1, $2) ORDER BY "select_models"."created_at" DESC, "select_models"."id" DESC`,
+			expectedArgs:  []interface{}{"2024-01-01", int64(5)},
+		},
+		{
+			name:          "select_with_mixed_direction_keyset",
+			dst:           selectModel{},
+			options:       []goqux.SelectOption{goqux.WithKeySetColumns([]goqux.KeySetColumn{goqux.KeySetDesc("CreatedAt"), goqux.KeySetAsc("ID")}, []any{"2024-01-01", 5})},
+			expectedQuery: `SELECT "select_models"."int_field" FROM "select_models" WHERE (("select_models"."created_at" < $1) OR (("select_models"."created_at" = $2) AND ("select_models"."id" > $3))) ORDER BY "select_models"."created_at" DESC, "select_models"."id" ASC`,
+			expectedArgs:  []interface{}{"2024-01-01", "2024-01-01", int64(5)},
+		},
+		{
+			name:          "select_with_nulls_last_keyset",
+			dst:           selectModel{},
+			options:       []goqux.SelectOption{goqux.WithKeySetColumns([]goqux.KeySetColumn{goqux.KeySetAsc("Priority").NullsLast(), goqux.KeySetAsc("ID")}, []any{1, 5})},
+			expectedQuery: `SELECT "select_models"."int_field" FROM "select_models" WHERE ((("select_models"."priority" > $1) OR ("select_models"."priority" IS NULL)) OR (("select_models"."priority" = $2) AND ("select_models"."id" > $3))) ORDER BY "select_models"."priority" ASC NULLS LAST, "select_models"."id" ASC`,
+			expectedArgs:  []interface{}{int64(1), int64(1), int64(5)},
+		},
+		{
+			name:          "select_with_nulls_last_keyset_null_value",
+			dst:           selectModel{},
+			options:       []goqux.SelectOption{goqux.WithKeySetColumns([]goqux.KeySetColumn{goqux.KeySetAsc("Priority").NullsLast(), goqux.KeySetAsc("ID")}, []any{nil, 5})},
+			expectedQuery: `SELECT "select_models"."int_field" FROM "select_models" WHERE (("select_models"."priority" IS NULL) AND ("select_models"."id" > $1)) ORDER BY "select_models"."priority" ASC NULLS LAST, "select_models"."id" ASC`,
+			expectedArgs:  []interface{}{int64(5)},
+		},
+		{
+			name:          "select_with_nulls_first_keyset_null_value",
+			dst:           selectModel{},
+			options:       []goqux.SelectOption{goqux.WithKeySetColumns([]goqux.KeySetColumn{goqux.KeySetDesc("Priority").NullsFirst(), goqux.KeySetAsc("ID")}, []any{nil, 5})},
+			expectedQuery: `SELECT "select_models"."int_field" FROM "select_models" WHERE (("select_models"."priority" IS NOT NULL) OR (("select_models"."priority" IS NULL) AND ("select_models"."id" > $1))) ORDER BY "select_models"."priority" DESC NULLS FIRST, "select_models"."id" ASC`,
+			expectedArgs:  []interface{}{int64(5)},
+		},
 		{
 			name: "select_with_inner_join_selection",
 			dst:  joinModel{},
@@ -0,0 +1,205 @@
+package goqux
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var serverCursorSequence atomic.Uint64
+
+// serverCursor is a postgres cursor declared inside its own transaction, the mutex guards the transaction
+// from being closed on context cancellation while a page is fetched.
+type serverCursor struct {
+	mu    sync.Mutex
+	begin func(ctx context.Context) (pgx.Tx, error)
+	tx    pgx.Tx
+	name  string
+	table string
+	query string
+	args  []any
+	// redacted rebuilds the query with its sensitive values redacted for a QueryError
+	redacted redactedQuery
+	// closeOnCancel is set when the cursor has a pooled connection of its own, so it can be closed from the
+	// goroutine of the cancelled context
+	closeOnCancel bool
+	closed        bool
+	stopClose     func() bool
+}
+
+func (c *serverCursor) open(ctx context.Context) error {
+	if c.tx != nil {
+		return nil
+	}
+	if c.closed {
+		return fmt.Errorf("goqux: server cursor is closed")
+	}
+	tx, err := c.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("goqux: failed to begin server cursor transaction: %w", err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", c.name, c.query), c.args...); err != nil {
+		_ = tx.Rollback(context.Background())
+		return newQueryError("declare server cursor", c.table, c.query, c.args, c.redacted, err)
+	}
+	c.tx = tx
+	if c.closeOnCancel {
+		// make sure the cursor and its transaction are released if the context is cancelled between pages
+		c.stopClose = context.AfterFunc(ctx, func() {
+			_ = c.Close()
+		})
+	}
+	return nil
+}
+
+// serverCursorBeginFunc returns the function beginning the cursor transaction on db, and whether the cursor can be
+// closed on context cancellation. On a pool the transaction is REPEATABLE READ on a connection of its own, so the total
+// count sees the same snapshot as the cursor. Inside a pgx.Tx the cursor runs in a savepoint, with the isolation level
+// of the outer transaction, on a connection the caller still owns.
+func serverCursorBeginFunc(db Querier) (func(ctx context.Context) (pgx.Tx, error), bool, error) {
+	switch db := db.(type) {
+	case pgx.Tx:
+		return db.Begin, false, nil
+	case *pgxpool.Pool:
+		return func(ctx context.Context) (pgx.Tx, error) {
+			return db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+		}, true, nil
+	default:
+		return nil, false, fmt.Errorf("goqux: server cursor pagination requires a *pgxpool.Pool or a pgx.Tx, got %T", db)
+	}
+}
+
+func (c *serverCursor) fetch(ctx context.Context, dst any, pageSize uint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.open(ctx); err != nil {
+		return err
+	}
+	fetchQuery := fmt.Sprintf("FETCH FORWARD %d FROM %s", pageSize, c.name)
+	rows, err := c.tx.Query(ctx, fetchQuery)
+	if err != nil {
+		return newQueryError("fetch from server cursor", c.table, fetchQuery, nil, nil, err)
+	}
+	if err := pgxscan.ScanAll(dst, rows); err != nil {
+		return newQueryError("fetch from server cursor", c.table, fetchQuery, nil, nil, err)
+	}
+	return nil
+}
+
+func (c *serverCursor) count(ctx context.Context, countQuery string, args []any, redacted redactedQuery) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.open(ctx); err != nil {
+		return 0, err
+	}
+	var count int64
+	if err := pgxscan.Get(ctx, c.tx, &count, countQuery, args...); err != nil {
+		return 0, newQueryError("count", c.table, countQuery, args, redacted, err)
+	}
+	return uint64(count), nil
+}
+
+func (c *serverCursor) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.close()
+}
+
+// close closes the cursor and commits its transaction, it runs with a background context as the
+// pagination context may already be cancelled.
+func (c *serverCursor) close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.tx == nil {
+		return nil
+	}
+	if c.stopClose != nil {
+		c.stopClose()
+	}
+	ctx := context.Background()
+	if _, err := c.tx.Exec(ctx, "CLOSE "+c.name); err != nil {
+		_ = c.tx.Rollback(ctx)
+		return fmt.Errorf("goqux: failed to close server cursor: %w", err)
+	}
+	if err := c.tx.Commit(ctx); err != nil {
+		return fmt.Errorf("goqux: failed to commit server cursor transaction: %w", translateError(err))
+	}
+	return nil
+}
+
+// SelectServerCursorPagination paginates over the select query with a postgres server side cursor, the query is declared as
+// a cursor inside a new REPEATABLE READ transaction on the first page, and each page is pulled with FETCH, giving a consistent
+// snapshot of the result set and of its total count. The cursor and its transaction are closed once there are no more
+// pages, when ctx is cancelled or when Paginator.Close is called. Only PageSize and CountTotal are used from paginationOptions.
+//
+// db is a *pgxpool.Pool or a pgx.Tx. A *pgx.Conn isn't accepted, as the cursor transaction would hold the connection and
+// run the caller's other queries inside it. Inside a pgx.Tx the cursor is declared in a savepoint and isn't closed from
+// another goroutine when ctx is cancelled, but by the next NextPage call or by Paginator.Close, so the caller must not
+// use the transaction concurrently.
+func SelectServerCursorPagination[T any](ctx context.Context, db Querier, tableName string, paginationOptions *PaginationOptions, options ...SelectOption) (*Paginator[T], error) {
+	if paginationOptions == nil {
+		paginationOptions = &PaginationOptions{
+			PageSize: 10,
+		}
+	}
+	pageSize := paginationOptions.PageSize
+	if pageSize == 0 {
+		return nil, fmt.Errorf("goqux: page size is required for server cursor pagination")
+	}
+	begin, closeOnCancel, err := serverCursorBeginFunc(db)
+	if err != nil {
+		return nil, err
+	}
+	sd := buildSelectDataset(tableName, new(T), options...)
+	query, args, err := sd.Prepared(true).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("goqux: failed to build select query: %w", err)
+	}
+	c := &serverCursor{
+		begin:         begin,
+		name:          pgx.Identifier{"goqux_cursor_" + strconv.FormatUint(serverCursorSequence.Add(1), 10)}.Sanitize(),
+		table:         tableName,
+		query:         query,
+		args:          args,
+		redacted:      redactedSelect(sd, reflect.TypeFor[T]()),
+		closeOnCancel: closeOnCancel,
+	}
+	p := NewPaginator(func(p *Paginator[T]) ([]T, bool, error) {
+		results := make([]T, 0)
+		if err := c.fetch(ctx, &results, pageSize); err != nil {
+			_ = c.Close()
+			return nil, false, err
+		}
+		if len(results) < int(pageSize) {
+			p.hasNext = false
+			if err := c.Close(); err != nil {
+				return nil, false, err
+			}
+		}
+		return results, false, nil
+	})
+	p.pageSize = pageSize
+	p.closer = c.Close
+	if paginationOptions.CountTotal {
+		p.counter = func() (uint64, error) {
+			countQuery, args, err := buildCountQuery(sd)
+			if err != nil {
+				return 0, fmt.Errorf("goqux: failed to build count query: %w", err)
+			}
+			return c.count(ctx, countQuery, args, redactedCount(sd, reflect.TypeFor[T]()))
+		}
+	}
+	return p, nil
+}
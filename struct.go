@@ -1,9 +1,10 @@
 package goqux
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
@@ -30,6 +31,16 @@ const (
 	omitEmpty = "omitempty"
 	// omitnil will skip the field if it is nil
 	omitNil = "omitnil"
+	// pk marks the field as part of the primary key, used by the ByPK helpers
+	primaryKey = "pk"
+	// unique marks the field as part of the conflict target of an upsert, instead of the primary key
+	unique = "unique"
+	// sensitive redacts the field value from the arguments of a QueryError
+	sensitive = "sensitive"
+	// table sets the table name of the struct on a blank _ field, i.e. _ struct{} `goqux:"table=billing.invoices"`
+	tableOption = "table"
+	// type sets the SQL type the field is cast to in the VALUES list of UpdateMany, i.e. `goqux:"type=numeric(10,2)"`
+	typeOption = "type"
 )
 
 func convertMapToSQLValuer(m map[string]any) map[string]SQLValuer {
@@ -40,123 +51,318 @@ func convertMapToSQLValuer(m map[string]any) map[string]SQLValuer {
 	return values
 }
 
-func encodeValues(v any, skipType string, skipZeroValues bool) map[string]SQLValuer {
+// ErrMissingPrimaryKey is returned by the ByPK helpers when the struct has no goqux:"pk" fields.
+var ErrMissingPrimaryKey = errors.New("goqux: missing primary key")
+
+// fieldMetadata is the cached metadata of an exported struct field.
+type fieldMetadata struct {
+	fieldTags
+	name  string
+	index []int
+	typ   reflect.Type
+}
+
+func (f *fieldMetadata) skip(skipType string) bool {
+	switch skipType {
+	case skipSelect:
+		return f.skipSelect
+	case skipUpdate:
+		return f.skipUpdate
+	case skipInsert:
+		return f.skipInsert
+	case skipReturningDelete:
+		return f.skipDelete
+	default:
+		return false
+	}
+}
+
+// structMetadata is the cached metadata of a struct type, built once per type by getStructMetadata.
+type structMetadata struct {
+	fields []fieldMetadata
+	byName map[string]*fieldMetadata
+	// pk are the primary key fields, in the struct order
+	pk []*fieldMetadata
+	// unique are the goqux:"unique" fields, the default conflict target of an upsert
+	unique []*fieldMetadata
+	// table is the table name set by the goqux:"table=..." tag
+	table string
+	err   error
+	// selection are the aliased columns of the struct fields of a join selection struct, built lazily as
+	// building it requires the metadata of the field types
+	selectionOnce sync.Once
+	selection     []exp.AliasedExpression
+	selectionErr  error
+}
+
+// field returns the metadata of the exported field with the given name.
+func (m *structMetadata) field(name string) (*fieldMetadata, bool) {
+	f, ok := m.byName[name]
+	return f, ok
+}
+
+// structMetadataCache maps a reflect.Type to its *structMetadata.
+var structMetadataCache sync.Map
+
+// getStructMetadata returns the cached metadata of the struct type t, pointer types are dereferenced.
+// An ErrInvalidTag error is returned if one of the fields has an invalid tag.
+func getStructMetadata(t reflect.Type) (*structMetadata, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	m, ok := structMetadataCache.Load(t)
+	if !ok {
+		m, _ = structMetadataCache.LoadOrStore(t, newStructMetadata(t))
+	}
+	metadata := m.(*structMetadata)
+	if metadata.err != nil {
+		return nil, metadata.err
+	}
+	return metadata, nil
+}
+
+func newStructMetadata(t reflect.Type) *structMetadata {
+	m := &structMetadata{byName: make(map[string]*fieldMetadata)}
+	if t.Kind() != reflect.Struct {
+		return m
+	}
+	// blank fields are only used as markers for struct level options, they are hidden by VisibleFields when there's more than one
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Name != "_" {
+			continue
+		}
+		tags, err := parseFieldTags(f)
+		if err != nil {
+			return &structMetadata{err: fmt.Errorf("%w (struct %s)", err, t)}
+		}
+		if tags.table != "" && m.table != "" {
+			return &structMetadata{err: fmt.Errorf("%w: struct %s has more than one table name", ErrInvalidTag, t)}
+		}
+		if tags.table != "" {
+			m.table = tags.table
+		}
+	}
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() {
+			continue
+		}
+		tags, err := parseFieldTags(f)
+		if err != nil {
+			return &structMetadata{err: fmt.Errorf("%w (struct %s)", err, t)}
+		}
+		if tags.ignore {
+			continue
+		}
+		if tags.column == "" {
+			tags.column = strcase.ToSnake(f.Name)
+		}
+		m.fields = append(m.fields, fieldMetadata{fieldTags: tags, name: f.Name, index: f.Index, typ: f.Type})
+	}
+	for i := range m.fields {
+		m.byName[m.fields[i].name] = &m.fields[i]
+		if m.fields[i].pk {
+			m.pk = append(m.pk, &m.fields[i])
+		}
+		if m.fields[i].unique {
+			m.unique = append(m.unique, &m.fields[i])
+		}
+	}
+	return m
+}
+
+// primaryKeyFilters returns the filters matching the primary key of key, key is either a struct with goqux:"pk" fields,
+// or the value of the single primary key of T.
+func primaryKeyFilters[T any](table exp.IdentifierExpression, key any) ([]exp.Expression, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	v := reflect.Indirect(reflect.ValueOf(key))
+	if v.Kind() == reflect.Struct {
+		metadata, err := getStructMetadata(v.Type())
+		if err != nil {
+			return nil, err
+		}
+		// structs without a primary key, such as time.Time, may be the value of the primary key of T
+		if len(metadata.pk) > 0 || v.Type() == t {
+			filters, _, err := structPrimaryKeyFilters(table, v)
+			return filters, err
+		}
+	}
+	metadata, err := getStructMetadata(t)
+	if err != nil {
+		return nil, err
+	}
+	switch len(metadata.pk) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s has no goqux:\"pk\" fields", ErrMissingPrimaryKey, t)
+	case 1:
+		return []exp.Expression{table.Col(metadata.pk[0].column).Eq(key)}, nil
+	default:
+		return nil, fmt.Errorf("goqux: %s has a composite primary key, the key must be a struct", t)
+	}
+}
+
+// structPrimaryKeyFilters returns the filters matching the goqux:"pk" fields of the struct v, and the primary key columns.
+func structPrimaryKeyFilters(table exp.IdentifierExpression, v reflect.Value) ([]exp.Expression, []string, error) {
+	metadata, err := getStructMetadata(v.Type())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(metadata.pk) == 0 {
+		return nil, nil, fmt.Errorf("%w: %s has no goqux:\"pk\" fields", ErrMissingPrimaryKey, v.Type())
+	}
+	filters := make([]exp.Expression, len(metadata.pk))
+	columns := make([]string, len(metadata.pk))
+	for i, f := range metadata.pk {
+		filters[i] = table.Col(f.column).Eq(v.FieldByIndex(f.index).Interface())
+		columns[i] = f.column
+	}
+	return filters, columns, nil
+}
+
+func encodeValues(v any, skipType string, skipZeroValues bool) (map[string]SQLValuer, error) {
 	t := reflect.ValueOf(v)
 	// if we received a map we will just convert it to a map of SQLValuer
 	if t.Kind() == reflect.Map {
-		return convertMapToSQLValuer(v.(map[string]any))
+		return convertMapToSQLValuer(v.(map[string]any)), nil
 	}
-	fields := reflect.VisibleFields(t.Type())
-	values := make(map[string]SQLValuer)
-	for _, f := range fields {
-		if !f.IsExported() || strings.Contains(f.Tag.Get(tagName), skipType) {
+	t = reflect.Indirect(t)
+	metadata, err := getStructMetadata(t.Type())
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]SQLValuer, len(metadata.fields))
+	for i := range metadata.fields {
+		f := &metadata.fields[i]
+		if f.skip(skipType) {
 			continue
 		}
-		value := t.FieldByName(f.Name)
+		value := t.FieldByIndex(f.index)
 		// We want to support the case when there is no value in one of the fields
 		if skipZeroValues && value.IsZero() {
 			continue
 		}
-
-		columnName := strcase.ToSnake(f.Name)
-		if dbTag := f.Tag.Get(tagNameDb); dbTag != "" {
-			if strings.Contains(dbTag, omitEmpty) {
-				if !value.IsValid() || value.IsZero() { // IsZero panic on valid values
-					continue
-				}
-				dbTag = cleanDbTag(dbTag, omitEmpty)
-			}
-			if strings.Contains(dbTag, omitNil) {
-				if value.IsNil() {
-					continue
-				}
-				dbTag = cleanDbTag(dbTag, omitNil)
-			}
-			columnName = dbTag
+		if f.omitEmpty && value.IsZero() {
+			continue
+		}
+		if f.omitNil && value.IsNil() {
+			continue
 		}
 
 		switch {
-		case strings.Contains(f.Tag.Get(tagName), defaultNowUtc):
-			values[columnName] = SQLValuer{time.Now().UTC()}
-		case strings.Contains(f.Tag.Get(tagName), defaultNow):
-			values[columnName] = SQLValuer{time.Now()}
+		case f.nowUtc:
+			values[f.column] = SQLValuer{time.Now().UTC()}
+		case f.now:
+			values[f.column] = SQLValuer{time.Now()}
 		default:
-			values[columnName] = SQLValuer{value.Interface()}
+			values[f.column] = SQLValuer{value.Interface()}
 		}
 	}
-	return values
+	return values, nil
 }
 
-func getColumnsFromStruct(table exp.IdentifierExpression, s any, skipType string) []exp.IdentifierExpression {
-	t := reflect.TypeOf(s)
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+// redactSensitiveValues replaces the encoded values of the goqux:"sensitive" fields of v with redactedArg, values encoded
+// as NULL are kept so the query has the same arguments.
+func redactSensitiveValues(v any, values map[string]SQLValuer) {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() == reflect.Map {
+		return
+	}
+	metadata, err := getStructMetadata(t)
+	if err != nil {
+		return
 	}
-	fields := reflect.VisibleFields(t)
-	var cols = make([]exp.IdentifierExpression, 0)
-	for _, f := range fields {
-		if !f.IsExported() || strings.Contains(f.Tag.Get(tagName), skipType) {
+	for i := range metadata.fields {
+		f := &metadata.fields[i]
+		value, ok := values[f.column]
+		if !f.sensitive || !ok {
 			continue
 		}
-		var colName string
-		if dbTag := f.Tag.Get(tagNameDb); dbTag != "" {
-			colName = cleanDbTag(dbTag, omitEmpty, omitNil)
-		} else {
-			colName = strcase.ToSnake(f.Name)
+		if driverValue, err := value.Value(); err == nil && (driverValue == nil || isNilPointer(driverValue)) {
+			continue
 		}
-		cols = append(cols, table.Col(colName))
+		values[f.column] = SQLValuer{redactedArg}
 	}
-	return cols
 }
 
-func cleanDbTag(tag string, tagsToClean ...string) string {
-	for _, tagToClean := range tagsToClean {
-		// Handle case where tag is just the partToClean
-		if tag == tagToClean {
-			return ""
-		}
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
 
-		// Handle case where tag starts with partToClean
-		if strings.HasPrefix(tag, fmt.Sprintf("%s,", tagToClean)) {
-			tag = strings.TrimPrefix(tag, fmt.Sprintf("%s,", tagToClean))
+// sensitiveColumns returns the columns of the goqux:"sensitive" fields of the models, and of their struct fields such as
+// the tables of a join selection.
+func sensitiveColumns(models ...reflect.Type) map[string]bool {
+	columns := make(map[string]bool)
+	visited := make(map[reflect.Type]bool)
+	var visit func(t reflect.Type)
+	visit = func(t reflect.Type) {
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice) {
+			t = t.Elem()
 		}
-
-		// Handle case where tag ends with partToClean
-		if strings.HasSuffix(tag, fmt.Sprintf(",%s", tagToClean)) {
-			tag = strings.TrimSuffix(tag, fmt.Sprintf(",%s", tagToClean))
+		if t == nil || t.Kind() != reflect.Struct || visited[t] {
+			return
 		}
-
-		// Handle case where tagToClean tag is in the middle
-		if strings.Contains(tag, fmt.Sprintf(",%s,", tagToClean)) {
-			tag = strings.ReplaceAll(tag, fmt.Sprintf(",%s,", tagToClean), ",")
+		visited[t] = true
+		metadata, err := getStructMetadata(t)
+		if err != nil {
+			return
+		}
+		for i := range metadata.fields {
+			f := &metadata.fields[i]
+			if f.sensitive {
+				columns[f.column] = true
+			}
+			visit(f.typ)
 		}
 	}
+	for _, t := range models {
+		visit(t)
+	}
+	return columns
+}
 
-	// Clean up any remaining commas
-	tag = strings.Trim(tag, ",")
-
-	return tag
+func getColumnsFromStruct(table exp.IdentifierExpression, s any, skipType string) ([]exp.IdentifierExpression, error) {
+	return getColumnsFromType(table, reflect.TypeOf(s), skipType)
 }
 
-func getSelectionFieldsFromSelectionStruct(s interface{}) []exp.AliasedExpression {
-	cols := make([]exp.AliasedExpression, 0)
-	t := reflect.TypeOf(s)
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+func getColumnsFromType(table exp.IdentifierExpression, t reflect.Type, skipType string) ([]exp.IdentifierExpression, error) {
+	metadata, err := getStructMetadata(t)
+	if err != nil {
+		return nil, err
 	}
-	tableFields := reflect.VisibleFields(t)
-	for _, tf := range tableFields {
-		if !tf.IsExported() {
+	var cols = make([]exp.IdentifierExpression, 0, len(metadata.fields))
+	for i := range metadata.fields {
+		if metadata.fields[i].skip(skipType) {
 			continue
 		}
-		if tf.Type.Kind() != reflect.Struct && !(tf.Type.Kind() == reflect.Ptr && tf.Type.Elem().Kind() == reflect.Struct) {
+		cols = append(cols, table.Col(metadata.fields[i].column))
+	}
+	return cols, nil
+}
+
+// getSelectionFieldsFromSelectionStruct returns the cached selection columns of the struct, the returned slice must not be modified.
+func getSelectionFieldsFromSelectionStruct(s interface{}) ([]exp.AliasedExpression, error) {
+	metadata, err := getStructMetadata(reflect.TypeOf(s))
+	if err != nil {
+		return nil, err
+	}
+	metadata.selectionOnce.Do(func() {
+		metadata.selection, metadata.selectionErr = selectionFields(metadata)
+	})
+	return metadata.selection, metadata.selectionErr
+}
+
+func selectionFields(metadata *structMetadata) ([]exp.AliasedExpression, error) {
+	cols := make([]exp.AliasedExpression, 0)
+	for _, tf := range metadata.fields {
+		if tf.typ.Kind() != reflect.Struct && !(tf.typ.Kind() == reflect.Ptr && tf.typ.Elem().Kind() == reflect.Struct) {
 			continue
 		}
-		tableName := strcase.ToSnake(tf.Name)
-		if dbTag := tf.Tag.Get(tagNameDb); dbTag != "" {
-			tableName = cleanDbTag(dbTag)
+		tableName := tf.column
+		subTableColumns, err := getColumnsFromType(goqu.T(tableName), tf.typ, skipSelect)
+		if err != nil {
+			return nil, err
 		}
-		subTableColumns := getColumnsFromStruct(goqu.T(tableName), reflect.New(tf.Type).Elem().Interface(), skipSelect)
 		for _, c := range subTableColumns {
 			// SELECT "table"."column" AS "table.column" will make sure dbscan scans all the columns correctly
 			cc := c.GetCol()
@@ -164,5 +370,5 @@ func getSelectionFieldsFromSelectionStruct(s interface{}) []exp.AliasedExpressio
 			cols = append(cols, goqu.T(tableName).Col(cc).As(goqu.C(cName)))
 		}
 	}
-	return cols
+	return cols, nil
 }
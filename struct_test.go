@@ -1,6 +1,8 @@
 package goqux
 
 import (
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -62,7 +64,7 @@ func TestEncodeValues(t *testing.T) {
 		{
 			name: "encode_map_values",
 			model: struct {
-				MapValue map[string]any `goqux:"skip_compare"`
+				MapValue map[string]any `goqux:"skip_update"`
 			}{MapValue: map[string]any{"type": "map"}},
 			values:         map[string]SQLValuer{"map_value": {map[string]any{"type": "map"}}},
 			skipFlag:       skipInsert,
@@ -167,20 +169,22 @@ func TestEncodeValues(t *testing.T) {
 	}
 	for _, tt := range tableTests {
 		t.Run(tt.name, func(t *testing.T) {
-			values := encodeValues(tt.model, tt.skipFlag, tt.skipZeroValues)
+			values, err := encodeValues(tt.model, tt.skipFlag, tt.skipZeroValues)
+			require.NoError(t, err)
 			assert.Equal(t, tt.values, values)
 		})
 	}
 }
 
 func TestEncodeTimeValue(t *testing.T) {
-	values := encodeValues(struct {
+	values, err := encodeValues(struct {
 		TimeField   *time.Time `goqux:"now"`
 		unexported  bool
 		FieldToSkip int `goqux:"skip_insert"`
 	}{
 		FieldToSkip: 5,
 	}, skipInsert, true)
+	require.NoError(t, err)
 	if tf, ok := values["time_field"]; ok {
 		require.NotNil(t, tf)
 		return
@@ -214,7 +218,8 @@ func TestGetColumnsFromStruct(t *testing.T) {
 	}
 	for _, tt := range tableTests {
 		t.Run(tt.name, func(t *testing.T) {
-			columns := getColumnsFromStruct(goqu.T("table"), tt.model, skipSelect)
+			columns, err := getColumnsFromStruct(goqu.T("table"), tt.model, skipSelect)
+			require.NoError(t, err)
 			assert.Equal(t, tt.expected, columns)
 		})
 	}
@@ -278,8 +283,102 @@ func Test_getSelectionFieldsFromSelectionStruct(t *testing.T) {
 	}
 	for _, tt := range tableTests {
 		t.Run(tt.name, func(t *testing.T) {
-			columns := getSelectionFieldsFromSelectionStruct(tt.model)
+			columns, err := getSelectionFieldsFromSelectionStruct(tt.model)
+			require.NoError(t, err)
 			assert.Equal(t, tt.expected, columns)
 		})
 	}
 }
+
+func TestStructMetadataCache(t *testing.T) {
+	type node struct {
+		ID     int64 `db:"node_id,omitempty"`
+		Parent *node
+		Name   string `goqux:"skip_update"`
+	}
+	metadata, err := getStructMetadata(reflect.TypeOf(node{}))
+	require.NoError(t, err)
+	ptrMetadata, err := getStructMetadata(reflect.TypeOf(&node{}))
+	require.NoError(t, err)
+	assert.Same(t, metadata, ptrMetadata)
+	id, ok := metadata.field("ID")
+	require.True(t, ok)
+	assert.Equal(t, "node_id", id.column)
+	assert.True(t, id.omitEmpty)
+	_, ok = metadata.field("Missing")
+	assert.False(t, ok)
+	// self referencing structs are only walked one level deep for join selection
+	selection, err := getSelectionFieldsFromSelectionStruct(node{})
+	require.NoError(t, err)
+	assert.Equal(t, []exp.AliasedExpression{
+		goqu.T("parent").Col("node_id").As(goqu.C("parent.node_id")),
+		goqu.T("parent").Col("parent").As(goqu.C("parent.parent")),
+		goqu.T("parent").Col("name").As(goqu.C("parent.name")),
+	}, selection)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values, err := encodeValues(node{ID: 1}, skipUpdate, false)
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]SQLValuer{"node_id": {int64(1)}, "parent": {(*node)(nil)}}, values)
+			cols, err := getColumnsFromStruct(goqu.T("node"), &node{}, skipSelect)
+			assert.NoError(t, err)
+			assert.Len(t, cols, 3)
+		}()
+	}
+	wg.Wait()
+}
+
+type benchmarkModel struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name,omitempty"`
+	Email     string    `goqux:"skip_update"`
+	Password  string    `goqux:"skip_select"`
+	CreatedAt time.Time `goqux:"now,skip_update"`
+	UpdatedAt time.Time `goqux:"now_utc"`
+	Priority  *int64    `db:"priority,omitnil"`
+}
+
+func BenchmarkStructMetadata(b *testing.B) {
+	t := reflect.TypeOf(benchmarkModel{})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			getStructMetadata(t)
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			newStructMetadata(t)
+		}
+	})
+}
+
+func BenchmarkEncodeValues(b *testing.B) {
+	b.ReportAllocs()
+	model := benchmarkModel{ID: 1, Name: "name"}
+	for range b.N {
+		encodeValues(model, skipInsert, false)
+	}
+}
+
+func BenchmarkGetColumnsFromStruct(b *testing.B) {
+	b.ReportAllocs()
+	table := goqu.T("table")
+	for range b.N {
+		getColumnsFromStruct(table, &benchmarkModel{}, skipSelect)
+	}
+}
+
+func BenchmarkKeySetValues(b *testing.B) {
+	b.ReportAllocs()
+	keyset := []KeySetColumn{KeySetAsc("CreatedAt"), KeySetAsc("ID")}
+	model := benchmarkModel{ID: 1}
+	for range b.N {
+		_, _ = keySetValues(model, keyset)
+	}
+}
@@ -0,0 +1,195 @@
+package goqux
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTag is returned when a goqux or db struct tag has unknown or conflicting options.
+var ErrInvalidTag = errors.New("goqux: invalid struct tag")
+
+// fieldTags is the parsed goqux and db tags of a struct field.
+type fieldTags struct {
+	// column is the column name set by the db tag, empty if not set
+	column string
+	// ignore is set by db:"-", the field isn't mapped to a column
+	ignore bool
+	// db tag options
+	omitEmpty bool
+	omitNil   bool
+	// goqux tag options
+	skipSelect bool
+	skipUpdate bool
+	skipInsert bool
+	skipDelete bool
+	now        bool
+	nowUtc     bool
+	pk         bool
+	unique     bool
+	sensitive  bool
+	// table is the table name set on a blank _ marker field, see TableNameOf
+	table string
+	// sqlType is the SQL type set by the type option, see UpdateMany
+	sqlType string
+}
+
+// tagToken is a single comma separated option of a struct tag, options may have a value i.e. key=value.
+type tagToken struct {
+	key   string
+	value string
+}
+
+func tokenizeTag(tag string) []tagToken {
+	tokens := make([]tagToken, 0)
+	for _, part := range splitTag(tag) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		tokens = append(tokens, tagToken{key: strings.TrimSpace(key), value: strings.TrimSpace(value)})
+	}
+	return tokens
+}
+
+// splitTag splits the tag on the commas outside of parentheses, so a type option may have a precision i.e. type=numeric(10,2).
+func splitTag(tag string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range tag {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth = max(0, depth-1)
+		case ',':
+			if depth == 0 {
+				parts = append(parts, tag[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tag[start:])
+}
+
+// parseFieldTags parses the goqux and db tags of the field, returning an ErrInvalidTag error for unknown,
+// duplicate or conflicting options.
+func parseFieldTags(f reflect.StructField) (fieldTags, error) {
+	var tags fieldTags
+	if err := tags.parseDbTag(f.Tag.Get(tagNameDb)); err != nil {
+		return tags, fmt.Errorf("%w: field %s: %s", ErrInvalidTag, f.Name, err)
+	}
+	if err := tags.parseGoquxTag(f.Tag.Get(tagName)); err != nil {
+		return tags, fmt.Errorf("%w: field %s: %s", ErrInvalidTag, f.Name, err)
+	}
+	if err := tags.validate(f.Type); err != nil {
+		return tags, fmt.Errorf("%w: field %s: %s", ErrInvalidTag, f.Name, err)
+	}
+	if tags.table != "" && f.Name != "_" {
+		return tags, fmt.Errorf("%w: field %s: goqux option %q must be set on a blank _ field", ErrInvalidTag, f.Name, tableOption)
+	}
+	return tags, nil
+}
+
+// parseDbTag parses the db tag, the options may appear anywhere in the tag and the only other token is the column name.
+func (t *fieldTags) parseDbTag(tag string) error {
+	if tag == "-" {
+		t.ignore = true
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, token := range tokenizeTag(tag) {
+		switch token.key {
+		case omitEmpty, omitNil:
+			if token.value != "" {
+				return fmt.Errorf("db option %q doesn't take a value", token.key)
+			}
+			if seen[token.key] {
+				return fmt.Errorf("duplicate db option %q", token.key)
+			}
+			seen[token.key] = true
+			t.omitEmpty = t.omitEmpty || token.key == omitEmpty
+			t.omitNil = t.omitNil || token.key == omitNil
+		default:
+			// the first token that isn't an option is the column name
+			if t.column != "" || token.value != "" {
+				return fmt.Errorf("unknown db option %q", strings.TrimSuffix(token.key+"="+token.value, "="))
+			}
+			t.column = token.key
+		}
+	}
+	return nil
+}
+
+func (t *fieldTags) parseGoquxTag(tag string) error {
+	seen := make(map[string]bool)
+	for _, token := range tokenizeTag(tag) {
+		if seen[token.key] {
+			return fmt.Errorf("duplicate goqux option %q", token.key)
+		}
+		seen[token.key] = true
+		if token.key == tableOption || token.key == typeOption {
+			if token.value == "" {
+				return fmt.Errorf("goqux option %q requires a value", token.key)
+			}
+			if token.key == tableOption {
+				t.table = token.value
+			} else {
+				t.sqlType = token.value
+			}
+			continue
+		}
+		var flag *bool
+		switch token.key {
+		case skipSelect:
+			flag = &t.skipSelect
+		case skipUpdate:
+			flag = &t.skipUpdate
+		case skipInsert:
+			flag = &t.skipInsert
+		case skipReturningDelete:
+			flag = &t.skipDelete
+		case defaultNow:
+			flag = &t.now
+		case defaultNowUtc:
+			flag = &t.nowUtc
+		case primaryKey:
+			flag = &t.pk
+		case unique:
+			flag = &t.unique
+		case sensitive:
+			flag = &t.sensitive
+		default:
+			return fmt.Errorf("unknown goqux option %q", token.key)
+		}
+		if token.value != "" {
+			return fmt.Errorf("goqux option %q doesn't take a value", token.key)
+		}
+		*flag = true
+	}
+	return nil
+}
+
+// validate checks the options are compatible with each other and with the field type.
+func (t *fieldTags) validate(typ reflect.Type) error {
+	if t.now && t.nowUtc {
+		return fmt.Errorf("conflicting goqux options %q and %q", defaultNow, defaultNowUtc)
+	}
+	if (t.now || t.nowUtc) && typ != timeType && typ != reflect.PointerTo(timeType) {
+		return fmt.Errorf("goqux options %q and %q require a time.Time field, got %s", defaultNow, defaultNowUtc, typ)
+	}
+	if t.omitNil {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		default:
+			return fmt.Errorf("db option %q requires a nillable field, got %s", omitNil, typ)
+		}
+	}
+	return nil
+}
+
+// timeType is the type of time.Time, used by the now options and parallel scans.
+var timeType = reflect.TypeOf(time.Time{})
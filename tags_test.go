@@ -0,0 +1,203 @@
+package goqux
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFieldTags(t *testing.T) {
+	tableTests := []struct {
+		name     string
+		model    any
+		expected fieldTags
+	}{
+		{
+			name: "no_tags",
+			model: struct {
+				Field int
+			}{},
+			expected: fieldTags{},
+		},
+		{
+			name: "column_and_options",
+			model: struct {
+				Field *int `db:"field_name,omitempty,omitnil" goqux:"skip_insert,skip_update"`
+			}{},
+			expected: fieldTags{column: "field_name", omitEmpty: true, omitNil: true, skipInsert: true, skipUpdate: true},
+		},
+		{
+			name: "sensitive",
+			model: struct {
+				Field string `goqux:"sensitive,skip_select"`
+			}{},
+			expected: fieldTags{sensitive: true, skipSelect: true},
+		},
+		{
+			name: "unique",
+			model: struct {
+				Field string `goqux:"unique"`
+			}{},
+			expected: fieldTags{unique: true},
+		},
+		{
+			name: "type",
+			model: struct {
+				Field float64 `goqux:"type=numeric(10, 2),skip_insert"`
+			}{},
+			expected: fieldTags{sqlType: "numeric(10, 2)", skipInsert: true},
+		},
+		{
+			name: "option_before_column",
+			model: struct {
+				Field *int `db:"omitnil,field_name"`
+			}{},
+			expected: fieldTags{column: "field_name", omitNil: true},
+		},
+		{
+			name: "only_option",
+			model: struct {
+				Field int `db:"omitempty"`
+			}{},
+			expected: fieldTags{omitEmpty: true},
+		},
+		{
+			name: "column_prefixed_with_option",
+			model: struct {
+				Field bool `db:"omitempty_flag"`
+			}{},
+			expected: fieldTags{column: "omitempty_flag"},
+		},
+		{
+			name: "now_utc_is_not_now",
+			model: struct {
+				Field time.Time `goqux:"now_utc"`
+			}{},
+			expected: fieldTags{nowUtc: true},
+		},
+		{
+			name: "now_time_pointer",
+			model: struct {
+				Field *time.Time `goqux:"now, skip_update"`
+			}{},
+			expected: fieldTags{now: true, skipUpdate: true},
+		},
+		{
+			name: "ignored",
+			model: struct {
+				Field int `db:"-"`
+			}{},
+			expected: fieldTags{ignore: true},
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := parseFieldTags(reflect.TypeOf(tt.model).Field(0))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tags)
+		})
+	}
+}
+
+func TestParseFieldTagsErrors(t *testing.T) {
+	tableTests := []struct {
+		name  string
+		model any
+		err   string
+	}{
+		{
+			name: "unknown_goqux_option",
+			model: struct {
+				Field int `goqux:"skip_compare"`
+			}{},
+			err: `goqux: invalid struct tag: field Field: unknown goqux option "skip_compare"`,
+		},
+		{
+			name: "unknown_db_option",
+			model: struct {
+				Field int `db:"field,omitzero"`
+			}{},
+			err: `goqux: invalid struct tag: field Field: unknown db option "omitzero"`,
+		},
+		{
+			name: "duplicate_option",
+			model: struct {
+				Field int `goqux:"skip_insert,skip_insert"`
+			}{},
+			err: `goqux: invalid struct tag: field Field: duplicate goqux option "skip_insert"`,
+		},
+		{
+			name: "option_with_value",
+			model: struct {
+				Field int `goqux:"skip_insert=true"`
+			}{},
+			err: `goqux: invalid struct tag: field Field: goqux option "skip_insert" doesn't take a value`,
+		},
+		{
+			name: "type_without_value",
+			model: struct {
+				Field int `goqux:"type="`
+			}{},
+			err: `goqux: invalid struct tag: field Field: goqux option "type" requires a value`,
+		},
+		{
+			name: "conflicting_now",
+			model: struct {
+				Field time.Time `goqux:"now,now_utc"`
+			}{},
+			err: `goqux: invalid struct tag: field Field: conflicting goqux options "now" and "now_utc"`,
+		},
+		{
+			name: "now_on_non_time",
+			model: struct {
+				Field string `goqux:"now"`
+			}{},
+			err: `goqux: invalid struct tag: field Field: goqux options "now" and "now_utc" require a time.Time field, got string`,
+		},
+		{
+			name: "omitnil_on_non_nillable",
+			model: struct {
+				Field int `db:"field,omitnil"`
+			}{},
+			err: `goqux: invalid struct tag: field Field: db option "omitnil" requires a nillable field, got int`,
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFieldTags(reflect.TypeOf(tt.model).Field(0))
+			require.ErrorIs(t, err, ErrInvalidTag)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestInvalidTagsAreReturnedByBuilders(t *testing.T) {
+	type invalidModel struct {
+		ID   int64
+		Name string `goqux:"skip_selct"`
+	}
+	_, _, err := BuildSelect("table", invalidModel{})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	_, _, err = BuildInsert("table", []any{invalidModel{}})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	_, _, err = BuildUpdate("table", invalidModel{ID: 1})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	_, _, err = BuildSelect("table", struct{ ID int64 }{}, WithInnerJoinSelection[struct{ Table invalidModel }]())
+	assert.ErrorIs(t, err, ErrInvalidTag)
+}
+
+func TestIgnoredFields(t *testing.T) {
+	type model struct {
+		ID       int64
+		Computed string `db:"-"`
+	}
+	query, _, err := BuildSelect("table", model{})
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "table"."id" FROM "table"`, query)
+	values, err := encodeValues(model{ID: 1, Computed: "value"}, skipInsert, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]SQLValuer{"id": {int64(1)}}, values)
+}
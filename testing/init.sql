@@ -1,6 +1,8 @@
 DROP TABLE IF EXISTS "insert_posts";
 DROP TABLE IF EXISTS "users";
 DROP TABLE IF EXISTS "select_users";
+DROP TABLE IF EXISTS "keyset_events";
+DROP TABLE IF EXISTS "upsert_users";
 
 
 CREATE TABLE IF NOT EXISTS "users"
@@ -52,3 +54,25 @@ BEGIN
         INSERT INTO "random_numbers" ("number") VALUES (floor(random() * 1000));
     END LOOP;
 END $$;
+
+
+-- keyset_events has many rows sharing the same created_at, used to test multi-column keyset pagination
+CREATE TABLE IF NOT EXISTS "keyset_events"
+(
+    "id"         SERIAL PRIMARY KEY,
+    "created_at" TIMESTAMP    NOT NULL,
+    "priority"   INTEGER      NULL
+);
+
+INSERT INTO "keyset_events" ("created_at", "priority")
+SELECT TIMESTAMP '2024-01-01 00:00:00' + (i / 3) * INTERVAL '1 hour', CASE WHEN i % 4 = 0 THEN NULL ELSE i % 5 END
+FROM generate_series(1, 50) AS i;
+
+-- upsert_users has a unique email, used to test upserts
+CREATE TABLE IF NOT EXISTS "upsert_users"
+(
+    "id"         SERIAL PRIMARY KEY,
+    "email"      VARCHAR(255) NOT NULL UNIQUE,
+    "name"       VARCHAR(255) NOT NULL,
+    "created_at" TIMESTAMP    NOT NULL DEFAULT NOW()
+);
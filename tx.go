@@ -0,0 +1,239 @@
+package goqux
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+const defaultTxRetryBackoff = 10 * time.Millisecond
+
+// savepointID numbers the savepoints of nested database/sql transactions.
+var savepointID atomic.Uint64
+
+// TxOptions are the options of a WithTx transaction.
+type TxOptions struct {
+	// IsoLevel is the isolation level of the transaction, defaults to the database default.
+	IsoLevel pgx.TxIsoLevel
+	// ReadOnly starts a read only transaction.
+	ReadOnly bool
+	// MaxRetries is the number of times the transaction is retried after a serialization failure or a deadlock
+	// (SQLSTATE 40001 or 40P01), defaults to no retries.
+	MaxRetries uint
+	// RetryBackoff is the delay before the first retry, doubled on each retry. Defaults to 10ms.
+	RetryBackoff time.Duration
+}
+
+// TxBeginner begins pgx transactions, it is implemented by *pgx.Conn and *pgxpool.Pool.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// sqlTxBeginner begins database/sql transactions, it is implemented by *sql.DB and *sql.Conn.
+type sqlTxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// transaction is a transaction started by WithTx.
+type transaction interface {
+	querier() Querier
+	commit(ctx context.Context) error
+	rollback(ctx context.Context) error
+}
+
+type pgxTransaction struct {
+	tx pgx.Tx
+}
+
+func (t pgxTransaction) querier() Querier                   { return t.tx }
+func (t pgxTransaction) commit(ctx context.Context) error   { return t.tx.Commit(ctx) }
+func (t pgxTransaction) rollback(ctx context.Context) error { return t.tx.Rollback(ctx) }
+
+type sqlTransaction struct {
+	tx *sql.Tx
+}
+
+func (t sqlTransaction) querier() Querier               { return NewSQLQuerier(t.tx) }
+func (t sqlTransaction) commit(context.Context) error   { return t.tx.Commit() }
+func (t sqlTransaction) rollback(context.Context) error { return t.tx.Rollback() }
+
+// sqlSavepoint is a nested database/sql transaction, pgx.Tx already implements nesting with savepoints.
+type sqlSavepoint struct {
+	tx   *sql.Tx
+	name string
+}
+
+func (s sqlSavepoint) querier() Querier { return NewSQLQuerier(s.tx) }
+
+func (s sqlSavepoint) commit(ctx context.Context) error {
+	_, err := s.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+s.name)
+	return err
+}
+
+func (s sqlSavepoint) rollback(ctx context.Context) error {
+	_, err := s.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+s.name)
+	return err
+}
+
+// WithTx runs fn in a transaction on db, it commits if fn returns nil and rolls back if fn returns an error or panics.
+// db is a *pgx.Conn, a *pgxpool.Pool, or a database/sql connection wrapped with NewSQLQuerier. Use the tx given to fn
+// for all the queries of the transaction. With opts.MaxRetries set, the whole transaction is retried with backoff when it
+// fails on a serialization failure or a deadlock, so fn must be safe to run more than once.
+//
+// If db is already a transaction, a pgx.Tx or a *sql.Tx wrapped with NewSQLQuerier, fn runs in a nested transaction:
+// a savepoint that is released if fn returns nil and rolled back to otherwise, leaving the outer transaction usable.
+// Nested transactions can't set the isolation level or read only, and aren't retried, a serialization failure
+// aborts the outer transaction which should be retried instead.
+func WithTx(ctx context.Context, db Querier, opts *TxOptions, fn func(tx Querier) error) error {
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+	begin, nested, err := txBeginFunc(db, opts)
+	if err != nil {
+		return err
+	}
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultTxRetryBackoff
+	}
+	for attempt := uint(0); ; attempt++ {
+		err := runTx(ctx, begin, fn)
+		if err == nil || nested || attempt >= opts.MaxRetries || !isRetryableTxError(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff << attempt):
+		}
+	}
+}
+
+// txBeginFunc returns the function beginning the transactions of db, nested is true if db is already a transaction.
+func txBeginFunc(db Querier, opts *TxOptions) (begin func(ctx context.Context) (transaction, error), nested bool, err error) {
+	switch db := db.(type) {
+	case pgx.Tx:
+		if err := validateNestedTxOptions(opts); err != nil {
+			return nil, true, err
+		}
+		return func(ctx context.Context) (transaction, error) {
+			tx, err := db.Begin(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return pgxTransaction{tx: tx}, nil
+		}, true, nil
+	case TxBeginner:
+		txOptions := pgx.TxOptions{IsoLevel: opts.IsoLevel}
+		if opts.ReadOnly {
+			txOptions.AccessMode = pgx.ReadOnly
+		}
+		return func(ctx context.Context) (transaction, error) {
+			tx, err := db.BeginTx(ctx, txOptions)
+			if err != nil {
+				return nil, err
+			}
+			return pgxTransaction{tx: tx}, nil
+		}, false, nil
+	case *SQLQuerier:
+		if tx, ok := db.db.(*sql.Tx); ok {
+			if err := validateNestedTxOptions(opts); err != nil {
+				return nil, true, err
+			}
+			return func(ctx context.Context) (transaction, error) {
+				name := fmt.Sprintf("goqux_savepoint_%d", savepointID.Add(1))
+				if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+					return nil, err
+				}
+				return sqlSavepoint{tx: tx, name: name}, nil
+			}, true, nil
+		}
+		beginner, ok := db.db.(sqlTxBeginner)
+		if !ok {
+			return nil, false, fmt.Errorf("goqux: %T can't begin a transaction", db.db)
+		}
+		isolation, err := sqlIsolationLevel(opts.IsoLevel)
+		if err != nil {
+			return nil, false, err
+		}
+		txOptions := &sql.TxOptions{Isolation: isolation, ReadOnly: opts.ReadOnly}
+		return func(ctx context.Context) (transaction, error) {
+			tx, err := beginner.BeginTx(ctx, txOptions)
+			if err != nil {
+				return nil, err
+			}
+			return sqlTransaction{tx: tx}, nil
+		}, false, nil
+	default:
+		return nil, false, fmt.Errorf("goqux: %T can't begin a transaction", db)
+	}
+}
+
+func validateNestedTxOptions(opts *TxOptions) error {
+	if opts.IsoLevel != "" || opts.ReadOnly {
+		return errors.New("goqux: a nested transaction can't set the isolation level or read only")
+	}
+	return nil
+}
+
+func runTx(ctx context.Context, begin func(ctx context.Context) (transaction, error), fn func(tx Querier) error) error {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("goqux: failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.rollback(context.WithoutCancel(ctx))
+			panic(p)
+		}
+	}()
+	if err := fn(tx.querier()); err != nil {
+		// roll back even if fn failed because ctx was cancelled
+		if rollbackErr := tx.rollback(context.WithoutCancel(ctx)); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("goqux: failed to rollback transaction: %w", rollbackErr))
+		}
+		return err
+	}
+	if err := tx.commit(ctx); err != nil {
+		return fmt.Errorf("goqux: failed to commit transaction: %w", translateError(err))
+	}
+	return nil
+}
+
+func sqlIsolationLevel(level pgx.TxIsoLevel) (sql.IsolationLevel, error) {
+	switch level {
+	case "":
+		return sql.LevelDefault, nil
+	case pgx.Serializable:
+		return sql.LevelSerializable, nil
+	case pgx.RepeatableRead:
+		return sql.LevelRepeatableRead, nil
+	case pgx.ReadCommitted:
+		return sql.LevelReadCommitted, nil
+	case pgx.ReadUncommitted:
+		return sql.LevelReadUncommitted, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("goqux: unknown isolation level %q", level)
+	}
+}
+
+// isRetryableTxError returns true for serialization failures and deadlocks, reported by pgx or lib/pq.
+func isRetryableTxError(err error) bool {
+	var code string
+	var pgErr *pgconn.PgError
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &pgErr):
+		code = pgErr.Code
+	case errors.As(err, &pqErr):
+		code = string(pqErr.Code)
+	}
+	return code == "40001" || code == "40P01"
+}
@@ -0,0 +1,196 @@
+package goqux
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+	serializationErr := &pq.Error{Code: "40001"}
+	tableTests := []struct {
+		name              string
+		opts              *TxOptions
+		queryErrs         []error
+		fn                func(tx Querier) error
+		expectedErr       error
+		expectedCommits   int
+		expectedRollbacks int
+		expectedTxOptions driver.TxOptions
+	}{
+		{
+			name:            "commit",
+			fn:              func(tx Querier) error { return nil },
+			expectedCommits: 1,
+		},
+		{
+			name:              "rollback_on_error",
+			fn:                func(tx Querier) error { return errFailed },
+			expectedErr:       errFailed,
+			expectedRollbacks: 1,
+		},
+		{
+			name:              "options",
+			opts:              &TxOptions{IsoLevel: pgx.Serializable, ReadOnly: true},
+			fn:                func(tx Querier) error { return nil },
+			expectedCommits:   1,
+			expectedTxOptions: driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSerializable), ReadOnly: true},
+		},
+		{
+			name:      "retry_serialization_failure",
+			opts:      &TxOptions{MaxRetries: 2, RetryBackoff: time.Millisecond},
+			queryErrs: []error{serializationErr, serializationErr},
+			fn: func(tx Querier) error {
+				_, err := Select[querierModel](context.Background(), tx, "models")
+				return err
+			},
+			expectedCommits:   1,
+			expectedRollbacks: 2,
+		},
+		{
+			name:      "retries_exhausted",
+			opts:      &TxOptions{MaxRetries: 1, RetryBackoff: time.Millisecond},
+			queryErrs: []error{serializationErr, serializationErr},
+			fn: func(tx Querier) error {
+				_, err := Select[querierModel](context.Background(), tx, "models")
+				return err
+			},
+			expectedErr:       serializationErr,
+			expectedRollbacks: 2,
+		},
+		{
+			name:      "no_retry_on_other_errors",
+			opts:      &TxOptions{MaxRetries: 3, RetryBackoff: time.Millisecond},
+			queryErrs: []error{&pq.Error{Code: "23505"}},
+			fn: func(tx Querier) error {
+				_, err := Select[querierModel](context.Background(), tx, "models")
+				return err
+			},
+			expectedErr:       &pq.Error{Code: "23505"},
+			expectedRollbacks: 1,
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &fakeDriver{columns: []string{"id", "name"}, queryErrs: tt.queryErrs}
+			err := WithTx(ctx, newFakeSQLQuerier(t, d), tt.opts, tt.fn)
+			if tt.expectedErr != nil {
+				assert.ErrorContains(t, err, tt.expectedErr.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedCommits, d.commits)
+			assert.Equal(t, tt.expectedRollbacks, d.rollbacks)
+			assert.Equal(t, tt.expectedTxOptions, d.txOptions[0])
+		})
+	}
+}
+
+func TestWithTxRollbackOnPanic(t *testing.T) {
+	d := &fakeDriver{}
+	querier := newFakeSQLQuerier(t, d)
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = WithTx(context.Background(), querier, nil, func(tx Querier) error {
+			panic("boom")
+		})
+	})
+	assert.Equal(t, 0, d.commits)
+	assert.Equal(t, 1, d.rollbacks)
+}
+
+// ctxTransaction records the context error seen by rollback.
+type ctxTransaction struct {
+	rollbackErr *error
+}
+
+func (t ctxTransaction) querier() Querier             { return nil }
+func (t ctxTransaction) commit(context.Context) error { return nil }
+func (t ctxTransaction) rollback(ctx context.Context) error {
+	*t.rollbackErr = ctx.Err()
+	return nil
+}
+
+func TestRunTxRollbackAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var rollbackErr error
+	begin := func(context.Context) (transaction, error) {
+		return ctxTransaction{rollbackErr: &rollbackErr}, nil
+	}
+	err := runTx(ctx, begin, func(Querier) error {
+		cancel()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoError(t, rollbackErr)
+}
+
+func TestWithTxNested(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+	d := &fakeDriver{}
+	err := WithTx(ctx, newFakeSQLQuerier(t, d), nil, func(tx Querier) error {
+		err := WithTx(ctx, tx, nil, func(tx Querier) error {
+			return WithTx(ctx, tx, nil, func(tx Querier) error {
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+		err = WithTx(ctx, tx, &TxOptions{MaxRetries: 3}, func(tx Querier) error {
+			return errFailed
+		})
+		assert.ErrorIs(t, err, errFailed)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, d.commits)
+	assert.Equal(t, 0, d.rollbacks)
+
+	// savepoint names are unique, name them by order of appearance
+	names := map[string]string{}
+	statements := make([]string, len(d.queries))
+	for i, q := range d.queries {
+		statement, name, _ := strings.Cut(q, "goqux_savepoint_")
+		if _, ok := names[name]; !ok {
+			names[name] = string(rune('a' + len(names)))
+		}
+		statements[i] = statement + names[name]
+	}
+	assert.Equal(t, []string{
+		"SAVEPOINT a",
+		"SAVEPOINT b",
+		"RELEASE SAVEPOINT b",
+		"RELEASE SAVEPOINT a",
+		"SAVEPOINT c",
+		"ROLLBACK TO SAVEPOINT c",
+	}, statements)
+
+	err = WithTx(ctx, newFakeSQLQuerier(t, &fakeDriver{}), nil, func(tx Querier) error {
+		return WithTx(ctx, tx, &TxOptions{ReadOnly: true}, func(tx Querier) error {
+			return nil
+		})
+	})
+	assert.ErrorContains(t, err, "nested transaction")
+}
+
+func TestIsRetryableTxError(t *testing.T) {
+	assert.True(t, isRetryableTxError(fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "40001"})))
+	assert.True(t, isRetryableTxError(&pgconn.PgError{Code: "40P01"}))
+	assert.True(t, isRetryableTxError(&pq.Error{Code: "40P01"}))
+	assert.False(t, isRetryableTxError(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, isRetryableTxError(errors.New("failed")))
+}
@@ -2,6 +2,8 @@ package goqux
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/doug-martin/goqu/v9/exp"
@@ -44,15 +46,70 @@ func WithUpdateSet(value any) UpdateOption {
 }
 
 func BuildUpdate(tableName string, value any, options ...UpdateOption) (string, []any, error) {
-	table := goqu.T(tableName)
+	q, err := buildUpdate(tableName, value, false, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	return q.ToSQL()
+}
+
+// buildUpdate builds the update dataset, with redact the goqux:"sensitive" values are replaced for a QueryError.
+func buildUpdate(tableName string, value any, redact bool, options ...UpdateOption) (*goqu.UpdateDataset, error) {
+	table := tableIdentifier(tableName)
 	q := goqu.Update(table).WithDialect(defaultDialect)
-	values := encodeValues(value, skipUpdate, true)
+	values, err := encodeValues(value, skipUpdate, true)
+	if err != nil {
+		return nil, err
+	}
 	if len(values) == 0 {
-		return "", nil, errors.New("no values to update")
+		return nil, errors.New("no values to update")
+	}
+	if redact {
+		redactSensitiveValues(value, values)
 	}
 	q = q.Set(values)
 	for _, o := range options {
 		q = o(table, q)
 	}
+	return q, nil
+}
+
+// BuildUpdateByPK builds an update query setting the non-zero fields of value on the row with the same primary key,
+// the goqux:"pk" fields are used to filter the row and are never updated.
+func BuildUpdateByPK(tableName string, value any, options ...UpdateOption) (string, []any, error) {
+	q, err := buildUpdateByPK(tableName, value, false, options...)
+	if err != nil {
+		return "", nil, err
+	}
 	return q.ToSQL()
 }
+
+func buildUpdateByPK(tableName string, value any, redact bool, options ...UpdateOption) (*goqu.UpdateDataset, error) {
+	table := tableIdentifier(tableName)
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("goqux: update by primary key requires a struct value, got %T", value)
+	}
+	filters, pkColumns, err := structPrimaryKeyFilters(table, v)
+	if err != nil {
+		return nil, err
+	}
+	values, err := encodeValues(value, skipUpdate, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range pkColumns {
+		delete(values, c)
+	}
+	if len(values) == 0 {
+		return nil, errors.New("no values to update")
+	}
+	if redact {
+		redactSensitiveValues(value, values)
+	}
+	q := goqu.Update(table).WithDialect(defaultDialect).Set(values).Where(filters...)
+	for _, o := range options {
+		q = o(table, q)
+	}
+	return q, nil
+}
@@ -0,0 +1,207 @@
+package goqux
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+)
+
+// updateManyAlias is the alias of the VALUES list of UpdateMany.
+const updateManyAlias = "goqux_values"
+
+// BuildUpdateMany builds a single update of the rows of values, each matched by its goqux:"pk" fields and set to its other
+// fields, skip_update fields aside. Zero values are set too, unlike BuildUpdate. The values are cast to the SQL type of
+// their field, inferred from the Go type or set with the goqux:"type=..." tag, which time fields require:
+//
+//	UPDATE "t" SET "name"="goqux_values"."name" FROM (VALUES ($1::bigint, $2::text), ...) AS "goqux_values" ("id", "name")
+//	WHERE ("t"."id" = "goqux_values"."id")
+func BuildUpdateMany[T any](tableName string, values []T, options ...UpdateOption) (string, []any, error) {
+	q, err := buildUpdateMany(tableName, values, false, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	return q.ToSQL()
+}
+
+// buildUpdateMany builds the update dataset of UpdateMany, with redact the goqux:"sensitive" values are replaced for a
+// QueryError.
+func buildUpdateMany[T any](tableName string, values []T, redact bool, options ...UpdateOption) (*goqu.UpdateDataset, error) {
+	if len(values) == 0 {
+		return nil, errors.New("no values to update")
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("goqux: update many requires struct values, got %s", t)
+	}
+	metadata, err := getStructMetadata(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata.pk) == 0 {
+		return nil, fmt.Errorf("%w: %s has no goqux:\"pk\" fields", ErrMissingPrimaryKey, t)
+	}
+	// the primary key fields come first, followed by the updated fields in the struct order
+	fields := make([]*fieldMetadata, 0, len(metadata.fields))
+	fields = append(fields, metadata.pk...)
+	for i := range metadata.fields {
+		if f := &metadata.fields[i]; !f.pk && !f.skipUpdate {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == len(metadata.pk) {
+		return nil, errors.New("no values to update")
+	}
+	casts := make([]string, len(fields))
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		sqlType, err := updateManySQLType(f)
+		if err != nil {
+			return nil, err
+		}
+		casts[i] = "?::" + sqlType
+		columns[i] = quoteIdentifier(f.column)
+	}
+	rowPlaceholders := "(" + strings.Join(casts, ", ") + ")"
+	rows := make([]string, len(values))
+	args := make([]any, 0, len(values)*len(fields))
+	for i, value := range values {
+		rows[i] = rowPlaceholders
+		encoded, err := encodeValues(value, skipUpdate, false)
+		if err != nil {
+			return nil, err
+		}
+		if redact {
+			redactSensitiveValues(value, encoded)
+		}
+		for _, f := range fields {
+			v, ok := encoded[f.column]
+			if !ok {
+				return nil, fmt.Errorf("goqux: update many requires all the values to set %s, remove its omitempty or omitnil option", f.column)
+			}
+			args = append(args, v)
+		}
+	}
+	from := goqu.L(fmt.Sprintf("(VALUES %s) AS %s (%s)", strings.Join(rows, ", "), quoteIdentifier(updateManyAlias), strings.Join(columns, ", ")), args...)
+	table := tableIdentifier(tableName)
+	source := goqu.T(updateManyAlias)
+	record := make(goqu.Record, len(fields)-len(metadata.pk))
+	for _, f := range fields[len(metadata.pk):] {
+		record[f.column] = source.Col(f.column)
+	}
+	filters := make([]goqu.Expression, len(metadata.pk))
+	for i, f := range metadata.pk {
+		filters[i] = table.Col(f.column).Eq(source.Col(f.column))
+	}
+	q := goqu.Update(table).WithDialect(defaultDialect).Set(record).From(from).Where(filters...)
+	for _, o := range options {
+		q = o(table, q)
+	}
+	return q, nil
+}
+
+// updateManySQLType returns the SQL type the values of the field are cast to in the VALUES list.
+func updateManySQLType(f *fieldMetadata) (string, error) {
+	if f.sqlType != "" {
+		return f.sqlType, nil
+	}
+	if sqlType, ok := sqlTypeOf(f.typ); ok {
+		return sqlType, nil
+	}
+	return "", fmt.Errorf("goqux: can't infer the SQL type of field %s (%s), set it with the goqux:\"type=...\" tag", f.name, f.typ)
+}
+
+var (
+	uuidType        = reflect.TypeOf(uuid.UUID{})
+	jsonRawType     = reflect.TypeOf(json.RawMessage{})
+	nullStringType  = reflect.TypeOf(sql.NullString{})
+	nullInt64Type   = reflect.TypeOf(sql.NullInt64{})
+	nullInt32Type   = reflect.TypeOf(sql.NullInt32{})
+	nullInt16Type   = reflect.TypeOf(sql.NullInt16{})
+	nullFloat64Type = reflect.TypeOf(sql.NullFloat64{})
+	nullBoolType    = reflect.TypeOf(sql.NullBool{})
+)
+
+// sqlTypeOf returns the Postgres type of the values of the Go type t, as encoded by SQLValuer. Time types aren't inferred,
+// as casting to the wrong one of timestamp and timestamptz silently shifts the values by the session time zone.
+func sqlTypeOf(t reflect.Type) (string, bool) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case uuidType:
+		return "uuid", true
+	case jsonRawType:
+		return "jsonb", true
+	case nullStringType:
+		return "text", true
+	case nullInt64Type:
+		return "bigint", true
+	case nullInt32Type:
+		return "integer", true
+	case nullInt16Type:
+		return "smallint", true
+	case nullFloat64Type:
+		return "double precision", true
+	case nullBoolType:
+		return "boolean", true
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "text", true
+	case reflect.Bool:
+		return "boolean", true
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "bigint", true
+	case reflect.Uint, reflect.Uint64:
+		// bigint can't hold the values above math.MaxInt64
+		return "numeric", true
+	case reflect.Int32, reflect.Uint16:
+		return "integer", true
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "smallint", true
+	case reflect.Float64:
+		return "double precision", true
+	case reflect.Float32:
+		return "real", true
+	case reflect.Map:
+		// maps are encoded as JSON by SQLValuer
+		return "jsonb", true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytea", true
+		}
+		if t.Elem().Kind() == reflect.Interface || t.Elem().Kind() == reflect.Map {
+			return "jsonb", true
+		}
+		if elem, ok := sqlTypeOf(t.Elem()); ok {
+			return elem + "[]", true
+		}
+	}
+	return "", false
+}
+
+// UpdateMany updates the rows of values in a single statement, see BuildUpdateMany. The updated rows are returned with a
+// returning option.
+func UpdateMany[T any](ctx context.Context, querier Querier, tableName string, values []T, options ...UpdateOption) ([]T, error) {
+	query, args, err := BuildUpdateMany(tableName, values, options...)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]T, 0)
+	if err := executorOf(querier).Select(ctx, &results, query, args...); err != nil {
+		return nil, newQueryError("update many", tableName, query, args, redactedUpdate(func() (*goqu.UpdateDataset, error) {
+			return buildUpdateMany(tableName, values, true, options...)
+		}, reflect.TypeFor[T]()), err)
+	}
+	return results, nil
+}
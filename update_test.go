@@ -2,7 +2,9 @@ package goqux_test
 
 import (
 	"errors"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/stretchr/testify/assert"
@@ -95,3 +97,116 @@ func TestBuildUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildUpdateByPK(t *testing.T) {
+	tableTests := []struct {
+		name          string
+		dst           interface{}
+		options       []goqux.UpdateOption
+		expectedQuery string
+		expectedArgs  []interface{}
+		expectedError error
+	}{
+		{
+			name:          "update_by_pk",
+			dst:           pkModel{ID: 5, Name: "name"},
+			expectedQuery: `UPDATE "pk_models" SET "name"=$1 WHERE ("pk_models"."id" = $2)`,
+			expectedArgs:  []interface{}{"name", int64(5)},
+		},
+		{
+			name:          "update_by_composite_pk_with_returning",
+			dst:           &compositePKModel{TenantID: 1, ID: 5, Name: "name"},
+			options:       []goqux.UpdateOption{goqux.WithUpdateReturningAll()},
+			expectedQuery: `UPDATE "pk_models" SET "name"=$1 WHERE (("pk_models"."tenant" = $2) AND ("pk_models"."id" = $3)) RETURNING *`,
+			expectedArgs:  []interface{}{"name", int64(1), int64(5)},
+		},
+		{
+			name:          "update_only_primary_key",
+			dst:           pkModel{ID: 5},
+			expectedError: errors.New("no values to update"),
+		},
+		{
+			name:          "update_without_primary_key",
+			dst:           updateModel{IntField: 5},
+			expectedError: goqux.ErrMissingPrimaryKey,
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := goqux.BuildUpdateByPK("pk_models", tt.dst, tt.options...)
+			if tt.expectedError != nil {
+				if errors.Is(tt.expectedError, goqux.ErrMissingPrimaryKey) {
+					assert.ErrorIs(t, err, tt.expectedError)
+				} else {
+					assert.Equal(t, tt.expectedError, err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+type updateManyModel struct {
+	ID        int64 `goqux:"pk"`
+	Name      string
+	Price     float64 `goqux:"type=numeric(10,2)"`
+	Tags      []string
+	CreatedAt time.Time `goqux:"skip_update"`
+}
+
+func TestBuildUpdateMany(t *testing.T) {
+	query, args, err := goqux.BuildUpdateMany("products", []updateManyModel{
+		{ID: 1, Name: "a", Price: 1.5, Tags: []string{"x"}},
+		{ID: 2},
+	}, goqux.WithUpdateReturningAll())
+	assert.NoError(t, err)
+	assert.Equal(t, `UPDATE "products" SET "name"="goqux_values"."name","price"="goqux_values"."price","tags"="goqux_values"."tags" `+
+		`FROM (VALUES ($1::bigint, $2::text, $3::numeric(10,2), $4::text[]), ($5::bigint, $6::text, $7::numeric(10,2), $8::text[])) `+
+		`AS "goqux_values" ("id", "name", "price", "tags") WHERE ("products"."id" = "goqux_values"."id") RETURNING *`, query)
+	assert.Equal(t, []interface{}{int64(1), "a", 1.5, `{"x"}`, int64(2), "", float64(0), nil}, args)
+
+	query, args, err = goqux.BuildUpdateMany("pk_models", []*compositePKModel{{TenantID: 1, ID: 5, Name: "name"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `UPDATE "pk_models" SET "name"="goqux_values"."name" FROM (VALUES ($1::bigint, $2::bigint, $3::text)) `+
+		`AS "goqux_values" ("tenant", "id", "name") WHERE (("pk_models"."tenant" = "goqux_values"."tenant") AND ("pk_models"."id" = "goqux_values"."id"))`, query)
+	assert.Equal(t, []interface{}{int64(1), int64(5), "name"}, args)
+
+	query, _, err = goqux.BuildUpdateMany("counters", []struct {
+		ID        int64 `goqux:"pk"`
+		Value     uint64
+		UpdatedAt time.Time `goqux:"type=timestamp"`
+	}{{ID: 1, Value: math.MaxUint64}})
+	assert.NoError(t, err)
+	assert.Equal(t, `UPDATE "counters" SET "updated_at"="goqux_values"."updated_at","value"="goqux_values"."value" `+
+		`FROM (VALUES ($1::bigint, $2::numeric, $3::timestamp)) AS "goqux_values" ("id", "value", "updated_at") `+
+		`WHERE ("counters"."id" = "goqux_values"."id")`, query)
+}
+
+func TestBuildUpdateManyErrors(t *testing.T) {
+	_, _, err := goqux.BuildUpdateMany("pk_models", []pkModel{})
+	assert.EqualError(t, err, "no values to update")
+	_, _, err = goqux.BuildUpdateMany("update_models", []updateModel{{IntField: 1}})
+	assert.ErrorIs(t, err, goqux.ErrMissingPrimaryKey)
+	_, _, err = goqux.BuildUpdateMany("pk_models", []struct {
+		ID int64 `goqux:"pk"`
+	}{{ID: 1}})
+	assert.EqualError(t, err, "no values to update")
+	_, _, err = goqux.BuildUpdateMany("pk_models", []struct {
+		ID    int64 `goqux:"pk"`
+		Value struct{ A int }
+	}{{ID: 1}})
+	assert.EqualError(t, err, `goqux: can't infer the SQL type of field Value (struct { A int }), set it with the goqux:"type=..." tag`)
+	_, _, err = goqux.BuildUpdateMany("pk_models", []struct {
+		ID        int64 `goqux:"pk"`
+		UpdatedAt time.Time
+	}{{ID: 1}})
+	assert.EqualError(t, err, `goqux: can't infer the SQL type of field UpdatedAt (time.Time), set it with the goqux:"type=..." tag`)
+	_, _, err = goqux.BuildUpdateMany("pk_models", []struct {
+		ID   int64  `goqux:"pk"`
+		Name string `db:"name,omitempty"`
+	}{{ID: 1, Name: "a"}, {ID: 2}})
+	assert.EqualError(t, err, "goqux: update many requires all the values to set name, remove its omitempty or omitnil option")
+}
@@ -0,0 +1,173 @@
+package goqux
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// OnConflict is the ON CONFLICT clause of an insert.
+type OnConflict struct {
+	// Columns are the conflict target. For an update they default to the goqux:"unique" fields of the inserted struct,
+	// or to its goqux:"pk" fields. DO NOTHING without a target skips the rows conflicting on any constraint.
+	Columns []string
+	// Constraint is the name of the conflict target constraint, used instead of Columns.
+	Constraint string
+	// DoNothing skips the conflicting rows instead of updating them.
+	DoNothing bool
+	// Update are the columns set from EXCLUDED on conflict, defaults to every inserted column that isn't part of the
+	// conflict target, a goqux:"pk" field or a goqux:"skip_update" field.
+	Update []string
+}
+
+// WithInsertOnConflict adds the ON CONFLICT clause to the insert.
+func WithInsertOnConflict(onConflict OnConflict) InsertOption {
+	return func(table exp.IdentifierExpression, s *goqu.InsertDataset) *goqu.InsertDataset {
+		return s.OnConflict(&upsertConflict{onConflict: onConflict})
+	}
+}
+
+// WithInsertOnConflictDoNothing skips the rows conflicting on the given columns, or on any constraint without columns.
+func WithInsertOnConflictDoNothing(columns ...string) InsertOption {
+	return WithInsertOnConflict(OnConflict{Columns: columns, DoNothing: true})
+}
+
+// WithInsertOnConflictDoUpdate updates the rows conflicting on the given columns, see OnConflict for the defaults.
+func WithInsertOnConflictDoUpdate(columns ...string) InsertOption {
+	return WithInsertOnConflict(OnConflict{Columns: columns})
+}
+
+// upsertConflict is set by the OnConflict options and resolved once the inserted values are known, as the default
+// target and updated columns depend on their struct tags.
+type upsertConflict struct {
+	onConflict OnConflict
+}
+
+func (c *upsertConflict) Expression() exp.Expression {
+	return c
+}
+
+func (c *upsertConflict) Clone() exp.Expression {
+	return &upsertConflict{onConflict: c.onConflict}
+}
+
+func (c *upsertConflict) Action() exp.ConflictAction {
+	if c.onConflict.DoNothing {
+		return exp.DoNothingConflictAction
+	}
+	return exp.DoUpdateConflictAction
+}
+
+// resolve returns the goqu conflict expression for the inserted values, and the conflict target of DO NOTHING which goqu
+// can't render.
+func (c *upsertConflict) resolve(values []any, encodedValues []map[string]SQLValuer) (exp.ConflictExpression, string, error) {
+	var metadata *structMetadata
+	if len(values) > 0 {
+		if t := reflect.TypeOf(values[0]); t != nil && t.Kind() != reflect.Map {
+			m, err := getStructMetadata(t)
+			if err != nil {
+				return nil, "", err
+			}
+			metadata = m
+		}
+	}
+	o := c.onConflict
+	target := conflictTarget(o.Columns, o.Constraint)
+	if o.DoNothing {
+		return goqu.DoNothing(), doNothingTarget(target), nil
+	}
+	columns := o.Columns
+	if target == "" && metadata != nil {
+		keys := metadata.unique
+		if len(keys) == 0 {
+			keys = metadata.pk
+		}
+		for _, f := range keys {
+			columns = append(columns, f.column)
+		}
+		target = conflictTarget(columns, "")
+	}
+	if target == "" {
+		return nil, "", fmt.Errorf("%w: an upsert requires conflict columns, a constraint or goqux:\"unique\" or goqux:\"pk\" fields", ErrMissingPrimaryKey)
+	}
+	update := o.Update
+	if update == nil {
+		update = defaultConflictUpdate(metadata, encodedValues, columns)
+	}
+	// nothing is left to update when all the inserted columns are part of the key
+	if len(update) == 0 {
+		return goqu.DoNothing(), doNothingTarget(target), nil
+	}
+	record := make(goqu.Record, len(update))
+	for _, col := range update {
+		record[col] = goqu.T("excluded").Col(col)
+	}
+	return goqu.DoUpdate(target, record), "", nil
+}
+
+// conflictTarget returns the conflict target as goqu expects it, the quoted columns without parentheses or ON CONSTRAINT name.
+func conflictTarget(columns []string, constraint string) string {
+	if constraint != "" {
+		return "ON CONSTRAINT " + quoteIdentifier(constraint)
+	}
+	if len(columns) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteIdentifier(c)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// doNothingTarget returns the conflict target as written after ON CONFLICT.
+func doNothingTarget(target string) string {
+	if target == "" || strings.HasPrefix(target, "ON CONSTRAINT") {
+		return target
+	}
+	return "(" + target + ")"
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func defaultConflictUpdate(metadata *structMetadata, encodedValues []map[string]SQLValuer, target []string) []string {
+	skip := make(map[string]bool, len(target))
+	for _, c := range target {
+		skip[c] = true
+	}
+	if metadata != nil {
+		for i := range metadata.fields {
+			if f := &metadata.fields[i]; f.pk || f.skipUpdate {
+				skip[f.column] = true
+			}
+		}
+	}
+	var update []string
+	for _, values := range encodedValues {
+		for col := range values {
+			if !skip[col] && !slices.Contains(update, col) {
+				update = append(update, col)
+			}
+		}
+	}
+	slices.Sort(update)
+	return update
+}
+
+// Upsert inserts value, or updates the conflicting row, see OnConflict for the default conflict target and updated columns.
+// Pass WithInsertOnConflict to change them, and WithInsertReturning to return the inserted or updated row.
+func Upsert[T any](ctx context.Context, querier Querier, tableName string, value any, options ...InsertOption) (*T, error) {
+	return Insert[T](ctx, querier, tableName, value, append([]InsertOption{WithInsertOnConflictDoUpdate()}, options...)...)
+}
+
+// UpsertMany is like Upsert for many values.
+func UpsertMany[T any](ctx context.Context, querier Querier, tableName string, values []any, options ...InsertOption) ([]T, error) {
+	return InsertMany[T](ctx, querier, tableName, values, append([]InsertOption{WithInsertOnConflictDoUpdate()}, options...)...)
+}